@@ -0,0 +1,305 @@
+// Package archive snapshots directories as gzipped tarballs and restores them.
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// MaxSize caps the total size of the files in a directory snapshot.
+	MaxSize = 1024 * 1024
+	// MaxFiles caps the number of files in a directory snapshot.
+	MaxFiles = 1000
+)
+
+var (
+	ErrTooLarge   = fmt.Errorf("directory is too large: max size is %dMB", MaxSize/(1024*1024))
+	ErrTooMany    = fmt.Errorf("directory has too many files: max is %d files", MaxFiles)
+	ErrUnsafePath = errors.New("archive contains a path outside of the destination")
+)
+
+// skippedDirs are never included in a snapshot.
+var skippedDirs = map[string]bool{".git": true, "node_modules": true, ".venv": true}
+
+// IsSkippedDir reports whether directories named name are left out of snapshots e.g .git.
+func IsSkippedDir(name string) bool {
+	return skippedDirs[name]
+}
+
+// Dir returns a gzipped tarball of the directory at dir.
+// Paths in the tarball are relative to the parent of dir, so extracting it recreates the directory.
+// Files for which allow returns false are skipped. allow is called with the absolute path of each file.
+func Dir(dir string, allow func(path string) bool) ([]byte, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	parent := filepath.Dir(dir)
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+
+	var size int64
+	var files int
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && skippedDirs[d.Name()] {
+			return filepath.SkipDir
+		}
+		if allow != nil && !allow(path) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() && info.Mode()&fs.ModeSymlink == 0 {
+			// sockets, devices etc can't be restored.
+			return nil
+		}
+
+		files++
+		if files > MaxFiles {
+			return ErrTooMany
+		}
+
+		var link string
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(parent, path)
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		// don't leak the user's name and ids.
+		hdr.Uid, hdr.Gid, hdr.Uname, hdr.Gname = 0, 0, "", ""
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		size += info.Size()
+		if size > MaxSize {
+			return ErrTooLarge
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// RewriteText returns a copy of the tarball data in which the content of every text file is replaced with what rewrite
+// returns for it. rewrite is called with the path of the file in the tarball. Binary files are copied as is.
+func RewriteText(data []byte, rewrite func(name, text string) string) ([]byte, error) {
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			if err := tw.WriteHeader(hdr); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		content, err := io.ReadAll(io.LimitReader(tr, MaxSize))
+		if err != nil {
+			return nil, err
+		}
+		if isText(content) {
+			content = []byte(rewrite(hdr.Name, string(content)))
+		}
+		hdr.Size = int64(len(content))
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func isText(content []byte) bool {
+	return bytes.IndexByte(content, 0) == -1 && utf8.Valid(content)
+}
+
+// Extract restores a tarball created by Dir in dest.
+func Extract(data []byte, dest string) error {
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer gr.Close()
+
+	dest, err = filepath.Abs(dest)
+	if err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dest, filepath.FromSlash(hdr.Name))
+		if !isWithin(dest, target) {
+			return fmt.Errorf("%w: %s", ErrUnsafePath, hdr.Name)
+		}
+		// Writing through a symlink restored by an earlier entry could write outside of dest.
+		if err := checkNoSymlinks(dest, target, hdr.Typeflag != tar.TypeSymlink); err != nil {
+			return fmt.Errorf("%w: %s", err, hdr.Name)
+		}
+
+		mode := hdr.FileInfo().Mode()
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, mode.Perm()|0700); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			linkTarget := hdr.Linkname
+			if !filepath.IsAbs(linkTarget) {
+				linkTarget = filepath.Join(filepath.Dir(target), linkTarget)
+			}
+			if !isWithin(dest, filepath.Clean(linkTarget)) {
+				return fmt.Errorf("%w: %s -> %s", ErrUnsafePath, hdr.Name, hdr.Linkname)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			os.Remove(target)
+			if err := os.Symlink(hdr.Linkname, target); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := writeFile(target, tr, mode.Perm()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// isWithin reports whether path is dir or inside of it.
+func isWithin(dir, path string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// checkNoSymlinks returns ErrUnsafePath if any existing parent of target below dest is a symlink.
+// If self is true, target must not be a symlink either.
+func checkNoSymlinks(dest, target string, self bool) error {
+	rel, err := filepath.Rel(dest, target)
+	if err != nil || rel == "." {
+		return err
+	}
+
+	parts := strings.Split(rel, string(filepath.Separator))
+	if !self {
+		parts = parts[:len(parts)-1]
+	}
+	path := dest
+	for _, part := range parts {
+		path = filepath.Join(path, part)
+		fi, err := os.Lstat(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if fi.Mode()&fs.ModeSymlink != 0 {
+			return ErrUnsafePath
+		}
+	}
+	return nil
+}
+
+func writeFile(path string, r io.Reader, perm fs.FileMode) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Limit what a single entry can write in case the archive is corrupt.
+	_, err = io.Copy(f, io.LimitReader(r, MaxSize))
+	return err
+}
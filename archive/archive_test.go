@@ -0,0 +1,165 @@
+package archive_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/getsavvyinc/savvy-cli/archive"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDirAndExtract(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "config")
+	assert.NoError(t, os.MkdirAll(filepath.Join(src, "nested"), 0755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(src, ".git"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(src, "app.yaml"), []byte("port: 8080\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(src, "nested", "run.sh"), []byte("echo hi\n"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(src, ".env"), []byte("TOKEN=secret\n"), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(src, ".git", "HEAD"), []byte("ref: main\n"), 0644))
+
+	allow := func(path string) bool {
+		return !strings.HasSuffix(path, ".env")
+	}
+	data, err := archive.Dir(src, allow)
+	assert.NoError(t, err)
+
+	dest := t.TempDir()
+	assert.NoError(t, archive.Extract(data, dest))
+
+	content, err := os.ReadFile(filepath.Join(dest, "config", "app.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "port: 8080\n", string(content))
+
+	fi, err := os.Stat(filepath.Join(dest, "config", "nested", "run.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), fi.Mode().Perm())
+
+	_, err = os.Stat(filepath.Join(dest, "config", ".env"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(dest, "config", ".git"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRewriteText(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "config")
+	assert.NoError(t, os.MkdirAll(src, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(src, "app.yaml"), []byte("token: abc123\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(src, "logo.png"), []byte("\x89PNG\x00abc123"), 0644))
+
+	data, err := archive.Dir(src, nil)
+	assert.NoError(t, err)
+
+	var names []string
+	rewritten, err := archive.RewriteText(data, func(name, text string) string {
+		names = append(names, name)
+		return strings.ReplaceAll(text, "abc123", "<token>")
+	})
+	assert.NoError(t, err)
+	// binary files aren't rewritten.
+	assert.Equal(t, []string{"config/app.yaml"}, names)
+
+	dest := t.TempDir()
+	assert.NoError(t, archive.Extract(rewritten, dest))
+
+	content, err := os.ReadFile(filepath.Join(dest, "config", "app.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "token: <token>\n", string(content))
+
+	content, err = os.ReadFile(filepath.Join(dest, "config", "logo.png"))
+	assert.NoError(t, err)
+	assert.Equal(t, "\x89PNG\x00abc123", string(content))
+}
+
+// tarball returns a gzipped tarball of the entries.
+func tarball(t *testing.T, entries ...*tar.Header) []byte {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, hdr := range entries {
+		content := "pwned\n"
+		if hdr.Typeflag == tar.TypeReg {
+			hdr.Size = int64(len(content))
+		}
+		assert.NoError(t, tw.WriteHeader(hdr))
+		if hdr.Typeflag == tar.TypeReg {
+			_, err := tw.Write([]byte(content))
+			assert.NoError(t, err)
+		}
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gw.Close())
+	return buf.Bytes()
+}
+
+func TestExtractHostileSymlinks(t *testing.T) {
+	outside := t.TempDir()
+
+	testCases := []struct {
+		name    string
+		entries []*tar.Header
+	}{
+		{
+			name: "absolute link",
+			entries: []*tar.Header{
+				{Name: "link", Typeflag: tar.TypeSymlink, Linkname: outside},
+				{Name: "link/file", Typeflag: tar.TypeReg, Mode: 0644},
+			},
+		},
+		{
+			name: "relative link",
+			entries: []*tar.Header{
+				{Name: "config/link", Typeflag: tar.TypeSymlink, Linkname: "../../" + filepath.Base(outside)},
+				{Name: "config/link/file", Typeflag: tar.TypeReg, Mode: 0644},
+			},
+		},
+		{
+			name: "write through a link inside dest",
+			entries: []*tar.Header{
+				{Name: "config/sub", Typeflag: tar.TypeDir, Mode: 0755},
+				{Name: "config/link", Typeflag: tar.TypeSymlink, Linkname: "sub"},
+				{Name: "config/link/file", Typeflag: tar.TypeReg, Mode: 0644},
+			},
+		},
+		{
+			name: "overwrite a link",
+			entries: []*tar.Header{
+				{Name: "config/sub", Typeflag: tar.TypeDir, Mode: 0755},
+				{Name: "config/link", Typeflag: tar.TypeSymlink, Linkname: "sub"},
+				{Name: "config/link", Typeflag: tar.TypeReg, Mode: 0644},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dest := filepath.Join(t.TempDir(), "a", "b")
+			assert.NoError(t, os.MkdirAll(dest, 0755))
+
+			err := archive.Extract(tarball(t, tc.entries...), dest)
+			assert.ErrorIs(t, err, archive.ErrUnsafePath)
+
+			_, err = os.Stat(filepath.Join(outside, "file"))
+			assert.True(t, os.IsNotExist(err))
+			_, err = os.Stat(filepath.Join(dest, "config", "sub", "file"))
+			assert.True(t, os.IsNotExist(err))
+		})
+	}
+
+	t.Run("links inside dest are restored", func(t *testing.T) {
+		dest := t.TempDir()
+		data := tarball(t,
+			&tar.Header{Name: "config/app.yaml", Typeflag: tar.TypeReg, Mode: 0644},
+			&tar.Header{Name: "config/current.yaml", Typeflag: tar.TypeSymlink, Linkname: "app.yaml"},
+		)
+		assert.NoError(t, archive.Extract(data, dest))
+
+		content, err := os.ReadFile(filepath.Join(dest, "config", "current.yaml"))
+		assert.NoError(t, err)
+		assert.Equal(t, "pwned\n", string(content))
+	})
+}
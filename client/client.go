@@ -9,14 +9,17 @@ import (
 	"io"
 	"io/fs"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/getsavvyinc/savvy-cli/authz"
 	"github.com/getsavvyinc/savvy-cli/config"
+	"github.com/getsavvyinc/savvy-cli/envcontext"
 	"github.com/getsavvyinc/savvy-cli/extension"
 	"github.com/getsavvyinc/savvy-cli/llm"
 	"github.com/getsavvyinc/savvy-cli/llm/service"
 	"github.com/getsavvyinc/savvy-cli/model"
+	"github.com/getsavvyinc/savvy-cli/slice"
 )
 
 type RunbookClient interface {
@@ -45,6 +48,8 @@ type StepContent struct {
 	Mode    fs.FileMode `json:"mode"`
 	Name    string      `json:"name"`
 	DirPath string      `json:"dir_path"`
+	// Type is StepTypeDirectory or StepTypePatch if Content is a tarball or a diff. It is empty for files.
+	Type StepTypeEnum `json:"type,omitempty"`
 }
 
 // UnmarshalJSON is a custom unmarshaler for StepContent that handles the mode as a float64.
@@ -161,18 +166,67 @@ type StepTypeEnum string
 const (
 	StepTypeCode StepTypeEnum = "code"
 	StepTypeFile StepTypeEnum = "file"
+	StepTypeNote StepTypeEnum = "note"
+	// StepTypeDirectory steps write a directory recorded as a tarball.
+	StepTypeDirectory StepTypeEnum = "directory"
+	// StepTypePatch steps apply a unified diff to a file that already exists.
+	StepTypePatch StepTypeEnum = "patch"
+	// StepTypeInteractive steps start an interactive session e.g psql and enter SubSteps in it.
+	StepTypeInteractive StepTypeEnum = "interactive"
 )
 
 type Step struct {
 	Type        StepTypeEnum `json:"type"`
 	Description string       `json:"description"`
 	Command     string       `json:"command"`
+	// SubSteps are the lines to enter in the interactive session that Command starts.
+	SubSteps []string `json:"sub_steps,omitempty"`
+	// Assert is what the step is expected to do. Runs flag steps that don't do what is expected.
+	Assert *Assertion `json:"assert,omitempty"`
 }
 
+// Assertion describes the expected outcome of a step e.g
+//
+//	{"contains": "successfully rolled out", "timeout": "5m"}
+type Assertion struct {
+	// ExitCode is the exit code the step is expected to exit with. Defaults to 0.
+	ExitCode *int `json:"exit_code,omitempty"`
+	// Contains is text the output of the step is expected to contain.
+	Contains string `json:"contains,omitempty"`
+	// Matches is a regular expression the output of the step is expected to match.
+	Matches string `json:"matches,omitempty"`
+	// Timeout is how long the step may take e.g 5m.
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// Runnable returns the shell command that runs the step.
+// Interactive sessions are replayed by feeding the sub-steps to the command as a heredoc.
+func (s Step) Runnable() string {
+	if len(s.SubSteps) == 0 {
+		return s.Command
+	}
+
+	delimiter := "SAVVY_EOF"
+	for slices.Contains(s.SubSteps, delimiter) {
+		delimiter += "_"
+	}
+	lines := append([]string{fmt.Sprintf("%s <<'%s'", s.Command, delimiter)}, s.SubSteps...)
+	lines = append(lines, delimiter)
+	return strings.Join(lines, "\n")
+}
+
+// RunnableSteps returns the steps of the runbook that run a command. Notes are only meant to be read.
+func (rb *Runbook) RunnableSteps() []Step {
+	return slice.Filter(rb.Steps, func(step Step) bool {
+		return step.Type != StepTypeNote
+	})
+}
+
+// Commands returns the commands of the runnable steps, so that the step at each index matches the steps of a run.
 func (rb *Runbook) Commands() []string {
 	var commands []string
-	for _, step := range rb.Steps {
-		commands = append(commands, step.Command)
+	for _, step := range rb.RunnableSteps() {
+		commands = append(commands, step.Runnable())
 	}
 	return commands
 }
@@ -185,6 +239,9 @@ func (c *client) GenerateRunbookV2(ctx context.Context, commands []model.Recorde
 
 	// Save the generated Runbook
 	clientRunbook := toClientRunbook(generatedRunbook)
+	if step, ok := preflightStep(commands); ok {
+		clientRunbook.Steps = append([]Step{step}, clientRunbook.Steps...)
+	}
 	if len(links) > 0 {
 		clientRunbook.Links = links
 	}
@@ -196,13 +253,36 @@ func (c *client) GenerateRunbookV2(ctx context.Context, commands []model.Recorde
 	return savedRunbook, nil
 }
 
+// preflightStep returns a note that lists the environment e.g kube context or AWS profile the commands were recorded against.
+func preflightStep(commands []model.RecordedCommand) (Step, bool) {
+	snapshots := slice.Map(commands, func(c model.RecordedCommand) *envcontext.Snapshot {
+		return c.Context
+	})
+
+	hints := envcontext.Preflight(snapshots)
+	if len(hints) == 0 {
+		return Step{}, false
+	}
+
+	description := "Before you start:\n- " + strings.Join(hints, "\n- ")
+	return Step{Type: StepTypeNote, Description: description}, true
+}
+
 func toClientRunbook(rb *llm.Runbook) *Runbook {
 	clientSteps := make([]Step, len(rb.Steps))
 	for i, step := range rb.Steps {
+		stepType := StepTypeCode
+		switch step.Type {
+		case llm.StepTypeNote:
+			stepType = StepTypeNote
+		case llm.StepTypeInteractive:
+			stepType = StepTypeInteractive
+		}
 		clientSteps[i] = Step{
-			Type:        StepTypeCode,
+			Type:        stepType,
 			Description: step.Description,
 			Command:     step.Command,
+			SubSteps:    step.SubSteps,
 		}
 	}
 	return &Runbook{
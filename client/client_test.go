@@ -0,0 +1,33 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/getsavvyinc/savvy-cli/envcontext"
+	"github.com/getsavvyinc/savvy-cli/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreflightStep(t *testing.T) {
+	commands := []model.RecordedCommand{
+		{Command: "kubectl get pods", Context: &envcontext.Snapshot{KubeContext: "staging"}},
+		{Command: "kubectl rollout restart deploy/api", Context: &envcontext.Snapshot{KubeContext: "staging"}},
+	}
+
+	step, ok := preflightStep(commands)
+	assert.True(t, ok)
+	assert.Equal(t, StepTypeNote, step.Type)
+	assert.Contains(t, step.Description, "kubectl config use-context staging")
+
+	rb := &Runbook{Steps: []Step{
+		step,
+		{Type: StepTypeCode, Command: "kubectl get pods"},
+		{Type: StepTypeCode, Command: "kubectl rollout restart deploy/api"},
+	}}
+	// the preflight note isn't a step to run.
+	assert.Equal(t, []string{"kubectl get pods", "kubectl rollout restart deploy/api"}, rb.Commands())
+	assert.Len(t, rb.RunnableSteps(), 2)
+
+	_, ok = preflightStep([]model.RecordedCommand{{Command: "ls"}})
+	assert.False(t, ok)
+}
@@ -53,8 +53,15 @@ func (m *GenerateRunbookModel) Generate() tea.Msg {
 	var commands []model.RecordedCommand
 	for _, cmd := range m.commands {
 		clientCmd := model.RecordedCommand{
-			Command: cmd.Command,
-			Prompt:  cmd.Prompt,
+			Command:    cmd.Command,
+			Prompt:     cmd.Prompt,
+			Output:     cmd.Output,
+			StartedAt:  cmd.StartedAt,
+			Duration:   cmd.Duration,
+			WorkingDir: cmd.WorkingDir,
+			Note:       cmd.Note,
+			Context:    cmd.Context,
+			SubSteps:   cmd.SubSteps,
 		}
 
 		if cmd.FileInfo != nil {
@@ -62,6 +69,7 @@ func (m *GenerateRunbookModel) Generate() tea.Msg {
 				Path:    cmd.FileInfo.Path,
 				Mode:    cmd.FileInfo.Mode,
 				Content: cmd.FileInfo.Content,
+				Type:    string(cmd.FileInfo.Type),
 			}
 		}
 
@@ -133,7 +141,7 @@ func toSteps(steps []client.Step) []RunbookStep {
 
 func toStep(step client.Step) RunbookStep {
 	return RunbookStep{
-		Command:     step.Command,
+		Command:     step.Runnable(),
 		Description: step.Description,
 	}
 }
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/charmbracelet/huh"
+	"github.com/getsavvyinc/savvy-cli/client"
+	"github.com/getsavvyinc/savvy-cli/cmd/internal"
+	"github.com/getsavvyinc/savvy-cli/display"
+	"github.com/getsavvyinc/savvy-cli/param"
+	"github.com/getsavvyinc/savvy-cli/server/run"
+	"github.com/getsavvyinc/savvy-cli/theme"
+	"golang.org/x/term"
+)
+
+// execRunbook runs all steps of the runbook without prompting and returns the exit code savvy run should exit with.
+func execRunbook(ctx context.Context, runbook *client.Runbook, opts ...run.Option) (int, error) {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	opts = append(opts, run.WithClassifier(internal.LoadClassifier()))
+	rsrv, err := run.NewServerWithSessionSocketPath(runbook, opts...)
+	if errors.Is(err, run.ErrAbortRun) {
+		display.Info("Run aborted")
+		return 1, nil
+	}
+	if err != nil {
+		return 1, err
+	}
+	defer rsrv.Close()
+	go rsrv.ListenAndServe()
+
+	cl, err := run.NewClient(ctx, rsrv.SocketPath())
+	if err != nil {
+		return 1, err
+	}
+
+	if err := seedParams(ctx, rsrv); err != nil {
+		return 1, err
+	}
+	if err := promptForUnsetParams(ctx, cl, rsrv.Commands()); err != nil {
+		return 1, err
+	}
+
+	executor := run.NewExecutor(cl,
+		run.WithContinueOnError(continueOnErrorFlag),
+		// let steps use savvy internal commands, just like they can in an interactive run.
+		run.WithEnv(run.SocketPathEnv+"="+rsrv.SocketPath()),
+		run.WithConfirm(confirmDangerousStep),
+	)
+	result, err := executor.Run(ctx)
+	if err != nil {
+		return 1, err
+	}
+
+	switch failed := result.Failed(); {
+	case result.Interrupted:
+		display.Info("Run interrupted")
+	case result.Declined:
+		display.ErrorMsg("Stopped at a dangerous step that wasn't confirmed")
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			display.Info("Set --allow-dangerous to run dangerous steps without confirmation")
+		}
+	case len(failed) == 0:
+		display.Successf("Ran %d steps of %s", len(result.Steps), runbook.Title)
+	default:
+		var steps []string
+		for _, step := range failed {
+			if len(step.Mismatches) > 0 {
+				steps = append(steps, fmt.Sprintf("%d (%s)", step.Index+1, strings.Join(step.Mismatches, ", ")))
+				continue
+			}
+			steps = append(steps, fmt.Sprintf("%d (exit code %d)", step.Index+1, step.ExitCode))
+		}
+		display.ErrorMsg(fmt.Sprintf("Failed steps: %s", strings.Join(steps, ", ")))
+	}
+	return result.ExitCode(), nil
+}
+
+// confirmDangerousStep asks the user whether the dangerous step may run.
+// Without a terminal to ask in, dangerous steps only run with --allow-dangerous.
+func confirmDangerousStep(state *run.State) (bool, error) {
+	if allowDangerousFlag {
+		return true, nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false, nil
+	}
+
+	var confirmed bool
+	err := huh.NewConfirm().
+		Title(fmt.Sprintf("Step %d is flagged as dangerous: %s", state.Index+1, state.Danger)).
+		Description(state.CommandWithSetParams()).
+		Affirmative("Run it").
+		Negative("Stop").
+		Value(&confirmed).
+		WithTheme(theme.New()).
+		Run()
+	if errors.Is(err, huh.ErrUserAborted) {
+		return false, nil
+	}
+	return confirmed, err
+}
+
+// promptForUnsetParams asks the user for the value of every param of the runbook that isn't set yet.
+// Steps run unattended, so params are resolved before the first step runs.
+// Without a terminal to prompt in, params fall back to their defaults.
+func promptForUnsetParams(ctx context.Context, cl run.Client, commands []*run.RunCommand) error {
+	state, err := cl.CurrentState()
+	if err != nil {
+		return err
+	}
+
+	var params []param.Param
+	for _, p := range runbookParams(commands) {
+		if _, ok := state.Params[p.Key()]; !ok {
+			params = append(params, p)
+		}
+	}
+	if len(params) == 0 {
+		return nil
+	}
+
+	var values map[string]string
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		if values, err = promptForParams(ctx, params, state.Params); err != nil {
+			return err
+		}
+	} else if values, err = defaultParamValues(params); err != nil {
+		return err
+	}
+
+	if err := cl.SetParams(values); err != nil {
+		return fmt.Errorf("failed to set params: %w", err)
+	}
+	return nil
+}
+
+func promptForParams(ctx context.Context, params []param.Param, known map[string]string) (map[string]string, error) {
+	return internal.PromptParams(ctx, "Set parameters for the runbook", "", params, known, theme.New())
+}
+
+func defaultParamValues(params []param.Param) (map[string]string, error) {
+	values := map[string]string{}
+	var missing []string
+	for _, p := range params {
+		if !p.HasDefault {
+			missing = append(missing, p.Key())
+			continue
+		}
+		values[p.Key()] = p.Default
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing values for the params: %s. Set them with --param, --param-file or %s<NAME>", strings.Join(missing, ", "), param.EnvPrefix)
+	}
+	return values, nil
+}
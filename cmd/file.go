@@ -8,6 +8,7 @@ import (
 
 	"github.com/getsavvyinc/savvy-cli/client"
 	"github.com/getsavvyinc/savvy-cli/display"
+	"github.com/getsavvyinc/savvy-cli/redact"
 	"github.com/getsavvyinc/savvy-cli/server"
 	"github.com/spf13/cobra"
 )
@@ -15,8 +16,12 @@ import (
 // fileCmd represents the file command
 var fileCmd = &cobra.Command{
 	Use:   "file",
-	Short: "Record contents of a file given its path",
-	Long:  `Record contents and filename of a file given its path.`,
+	Short: "Record contents of a file or directory given its path",
+	Long: `Record contents and filename of a file or directory given its path.
+
+Directories are recorded as a tarball. If the file was already recorded in this session and has changed since,
+only the changes are recorded as a diff that savvy write applies to the file.
+Record a file before editing it to record the edit as a diff.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		_, err := client.New()
 		if err != nil && errors.Is(err, client.ErrInvalidClient) {
@@ -35,19 +40,20 @@ var fileCmd = &cobra.Command{
 		}
 
 		filePath := strings.TrimSpace(args[0])
-		fi, err := os.Stat(filePath)
-		if err != nil {
+		// Directories are recorded as a tarball. Files that changed since they were first recorded are recorded as a diff.
+		if err := server.CheckFile(filePath); err != nil {
 			display.Error(err)
 			return
 		}
 
-		if fi.IsDir() {
-			display.ErrorMsg("file path provided is a directory")
+		rules, err := redact.LoadRules()
+		if err != nil {
+			display.Error(err)
 			return
 		}
 
-		if fi.Size() == 0 {
-			display.ErrorMsg("file provided is empty")
+		if !rules.AllowsFile(filePath) {
+			display.ErrorMsg("file is excluded from recordings by your redaction rules")
 			return
 		}
 
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/getsavvyinc/savvy-cli/display"
+	"github.com/getsavvyinc/savvy-cli/redact"
+	"github.com/getsavvyinc/savvy-cli/script"
+	"github.com/getsavvyinc/savvy-cli/server"
+	"github.com/getsavvyinc/savvy-cli/slice"
+)
+
+var fromScript string
+var fromTypescript string
+var promptRegex string
+
+func isHeadlessRecording() bool {
+	return fromScript != "" || fromTypescript != ""
+}
+
+// runHeadlessRecording records the commands in --from-script or --from-typescript without spawning a shell.
+func runHeadlessRecording(ctx context.Context, rules *redact.Rules) {
+	recordedCommands, err := readHeadlessRecording()
+	if err != nil {
+		display.Error(err)
+		os.Exit(1)
+	}
+
+	if len(recordedCommands) == 0 {
+		display.Error(errors.New("No commands were found"))
+		return
+	}
+
+	if err := exportRecordedCommands(ctx, recordedCommands, rules); err != nil {
+		display.ErrorWithSupportCTA(err)
+		os.Exit(1)
+	}
+}
+
+func readHeadlessRecording() ([]*server.RecordedCommand, error) {
+	if fromScript != "" {
+		f, err := os.Open(fromScript)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return script.ParseScript(f)
+	}
+
+	var prompt *regexp.Regexp
+	if promptRegex != "" {
+		var err error
+		if prompt, err = regexp.Compile(promptRegex); err != nil {
+			return nil, fmt.Errorf("invalid --prompt-regex: %w", err)
+		}
+	}
+
+	policy, err := server.LoadIgnorePolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(fromTypescript)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	recordedCommands, err := script.ParseTypescript(f, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	// typescripts are interactive sessions, so they are as noisy as a live recording.
+	return slice.Filter(recordedCommands, func(rc *server.RecordedCommand) bool {
+		return !policy.Ignores(server.RecordedData{Command: rc.Command})
+	}), nil
+}
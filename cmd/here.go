@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/getsavvyinc/savvy-cli/display"
+	"github.com/getsavvyinc/savvy-cli/redact"
+	"github.com/getsavvyinc/savvy-cli/server"
+	"github.com/getsavvyinc/savvy-cli/server/mode"
+	"github.com/getsavvyinc/savvy-cli/shell"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var recordHere bool
+
+// runRecordHere starts a recording session in the background and prints the shell code that makes the current shell record to it.
+// It is meant to be used as: eval "$(savvy record --here)"
+func runRecordHere() {
+	if term.IsTerminal(int(os.Stdout.Fd())) {
+		display.Info(`To record commands in this shell, run: eval "$(savvy record --here)"`)
+		return
+	}
+
+	if os.Getenv("SAVVY_CONTEXT") != "" {
+		display.ErrorMsg("This shell is already recording or running a runbook")
+		os.Exit(1)
+	}
+
+	socketPath, err := server.NewSessionSocketPath(mode.Record)
+	if err != nil {
+		display.ErrorWithSupportCTA(err)
+		os.Exit(1)
+	}
+
+	journal, err := server.NewJournal()
+	if err != nil {
+		display.ErrorWithSupportCTA(err)
+		os.Exit(1)
+	}
+	// The daemon appends to the journal.
+	journal.Close()
+
+	snippet, err := shell.New(socketPath).RecordHereSnippet(journal.Path())
+	if err != nil {
+		journal.Remove()
+		display.Error(err)
+		os.Exit(1)
+	}
+
+	if err := startRecordDaemon(socketPath, journal.Path()); err != nil {
+		journal.Remove()
+		display.ErrorWithSupportCTA(err)
+		os.Exit(1)
+	}
+
+	fmt.Print(snippet)
+	// stdout is eval'd by the shell, so talk to the user on stderr.
+	fmt.Fprintln(os.Stderr, "Recording commands in this shell. Run 'savvy record stop' when you are done.")
+}
+
+// daemonStartTimeout is how long to wait for the recording session to start or stop.
+const daemonStartTimeout = 5 * time.Second
+
+// startRecordDaemon starts a recording session that outlives savvy record --here.
+// It returns once the session is ready to accept commands.
+func startRecordDaemon(socketPath, journalPath string) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	args := []string{"record", "daemon", "--socket", socketPath, "--journal", journalPath}
+	if ignoreErrors {
+		args = append(args, "--ignore-errors")
+	}
+	if noContext {
+		args = append(args, "--no-context")
+	}
+
+	c := exec.Command(executable, args...)
+	// Detach from the terminal so that the daemon keeps running after savvy record --here exits.
+	c.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := c.Start(); err != nil {
+		return fmt.Errorf("failed to start recording session: %w", err)
+	}
+
+	exited := make(chan error, 1)
+	go func() { exited <- c.Wait() }()
+
+	deadline := time.After(daemonStartTimeout)
+	for {
+		if _, err := os.Stat(socketPath); err == nil {
+			return nil
+		}
+		select {
+		case err := <-exited:
+			return fmt.Errorf("%w: %v", server.ErrStartingRecordingSession, err)
+		case <-deadline:
+			c.Process.Kill()
+			return fmt.Errorf("%w: timed out", server.ErrStartingRecordingSession)
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}
+
+var daemonSocketPath string
+var daemonJournalPath string
+
+// recordDaemonCmd runs the recording session started by savvy record --here.
+var recordDaemonCmd = &cobra.Command{
+	Use:    "daemon",
+	Hidden: true,
+	Short:  "Run a recording session in the background",
+	Run: func(cmd *cobra.Command, args []string) {
+		// Anything the daemon prints is lost, so only exit with a non zero status on error.
+		rules, err := redact.LoadRules()
+		if err != nil {
+			os.Exit(1)
+		}
+
+		ignorePolicy, err := server.LoadIgnorePolicy()
+		if err != nil {
+			os.Exit(1)
+		}
+
+		journal, err := server.OpenJournal(daemonJournalPath)
+		if err != nil {
+			os.Exit(1)
+		}
+
+		ss, err := server.NewUnixSocketServer(daemonSocketPath,
+			server.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+			server.WithIgnoreErrors(ignoreErrors),
+			server.WithJournal(journal),
+			server.WithFileFilter(rules.AllowsFile),
+			server.WithIgnorePolicy(ignorePolicy),
+			server.WithContextSnapshots(!noContext),
+		)
+		if err != nil {
+			os.Exit(1)
+		}
+		defer ss.Close()
+
+		// ListenAndServe returns once savvy record stop shuts the session down.
+		ss.ListenAndServe()
+	},
+}
+
+// stopRecordDaemon shuts down the recording session at socketPath and waits for it to exit.
+func stopRecordDaemon(ctx context.Context, socketPath string) error {
+	cl, err := server.NewClient(ctx, socketPath)
+	if err != nil {
+		return err
+	}
+	if err := cl.SendShutdown(); err != nil {
+		return fmt.Errorf("failed to stop recording session: %w", err)
+	}
+
+	// The socket is removed once the session stops accepting commands.
+	deadline := time.Now().Add(daemonStartTimeout)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(socketPath); errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return errors.New("timed out waiting for the recording session to stop")
+}
+
+// recordStopCmd stops the recording session started by savvy record --here and exports the recorded commands.
+var recordStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop recording commands in this shell",
+	Long:  `Stop the recording session started with 'savvy record --here' and create a runbook from the recorded commands.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		socketPath := os.Getenv(server.SocketPathEnv)
+		journalPath := os.Getenv(server.JournalPathEnv)
+		if os.Getenv(server.TerminalEnv) != "" {
+			display.ErrorMsg("This terminal joined a recording session. Stop the session in the terminal that started it.")
+			os.Exit(1)
+		}
+		if os.Getenv(shell.RecordHereEnv) == "" || socketPath == "" || journalPath == "" {
+			display.Error(errors.New("this shell isn't recording. Start recording with: eval \"$(savvy record --here)\""))
+			os.Exit(1)
+		}
+
+		rules, err := redact.LoadRules()
+		if err != nil {
+			display.Error(err)
+			os.Exit(1)
+		}
+
+		if err := stopRecordDaemon(ctx, socketPath); err != nil {
+			display.ErrorWithSupportCTA(err)
+			os.Exit(1)
+		}
+
+		journal, err := server.OpenJournal(journalPath)
+		if err != nil {
+			display.ErrorWithSupportCTA(err)
+			os.Exit(1)
+		}
+
+		recordedCommands, err := journal.Commands()
+		if err != nil {
+			display.ErrorWithSupportCTA(err)
+			os.Exit(1)
+		}
+
+		if len(recordedCommands) == 0 {
+			journal.Remove()
+			display.Error(errors.New("No commands were recorded"))
+			return
+		}
+
+		if err := exportRecordedCommands(ctx, recordedCommands, rules); err != nil {
+			journal.Close()
+			display.ErrorWithSupportCTA(err)
+			display.Info("Your recording was saved. Run 'savvy record recover' to try again.")
+			os.Exit(1)
+		}
+		journal.Remove()
+	},
+}
+
+func init() {
+	recordDaemonCmd.Flags().StringVar(&daemonSocketPath, "socket", "", "socket the recording session listens on")
+	recordDaemonCmd.Flags().StringVar(&daemonJournalPath, "journal", "", "journal of the recording session")
+	recordDaemonCmd.Flags().BoolVar(&ignoreErrors, "ignore-errors", false, "Ignore commands that return an error when recording commands")
+	recordDaemonCmd.Flags().BoolVar(&noContext, "no-context", false, "Don't record the environment each command runs against")
+	recordDaemonCmd.MarkFlagRequired("socket")
+	recordDaemonCmd.MarkFlagRequired("journal")
+
+	recordCmd.AddCommand(recordDaemonCmd)
+	recordCmd.AddCommand(recordStopCmd)
+}
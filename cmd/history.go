@@ -54,7 +54,7 @@ func recordHistory(cmd *cobra.Command, _ []string) {
 
 	links, err := getLinks(ctx)
 	if err != nil {
-		fmt.Errorf("failed to get links from Savvy's Chrome Extension: %w", err)
+		err = fmt.Errorf("failed to get links from Savvy's Chrome Extension: %w", err)
 		display.ErrorWithSupportCTA(err)
 	}
 
@@ -250,8 +250,8 @@ func selectAndExpandHistory(ctx context.Context, logger *slog.Logger) ([]*server
 	ctx, cancelCtx := context.WithCancel(ctx)
 	defer cancelCtx()
 
-	sh := shell.New("/tmp/savvy-socket")
-	lines, err := sh.TailHistory(ctx)
+	// The socket path is only used when the shell is spawned, which happens after the server is created.
+	lines, err := shell.New("").TailHistory(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -269,7 +269,7 @@ func selectAndExpandHistory(ctx context.Context, logger *slog.Logger) ([]*server
 		commandProcessedChan <- true
 	}
 
-	ss, err := server.NewUnixSocketServerWithDefaultPath(server.WithCommandRecordedHook(hook))
+	ss, err := server.NewUnixSocketServerWithSessionPath(server.WithCommandRecordedHook(hook))
 	if errors.Is(err, server.ErrAbortRecording) {
 		display.Info("Recording aborted")
 		return nil, nil
@@ -280,6 +280,9 @@ func selectAndExpandHistory(ctx context.Context, logger *slog.Logger) ([]*server
 	}
 	defer ss.Close()
 
+	sh := shell.New(ss.SocketPath())
+	defer sh.Close()
+
 	go func() {
 		ss.ListenAndServe()
 		// kill b/g shell if we exit early
@@ -312,7 +315,12 @@ func selectAndExpandHistory(ctx context.Context, logger *slog.Logger) ([]*server
 		logger.Debug("failed to run spinner", "error", err.Error())
 	}
 
-	redacted, err := redact.Commands(commands)
+	rules, err := redact.LoadRules()
+	if err != nil {
+		return nil, err
+	}
+
+	redacted, err := redactCommands(commands, rules)
 	if err != nil {
 		logger.Debug("failed to redact commands", "error", err.Error())
 		return nil, err
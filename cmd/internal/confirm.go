@@ -0,0 +1,95 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/huh"
+	"github.com/getsavvyinc/savvy-cli/display"
+	"github.com/getsavvyinc/savvy-cli/server/run"
+	"github.com/spf13/cobra"
+)
+
+// confirmCmd represents the confirm command
+var confirmCmd = &cobra.Command{
+	Use:    "confirm",
+	Hidden: true,
+	Short:  "Confirm the current step of the runbook if it is flagged as dangerous",
+	Long: `Confirm asks the user to confirm the current step if it is flagged as dangerous, or to skip it instead.
+  It prints the index of the current step once it is confirmed or skipped.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		cl, err := run.NewDefaultClient(ctx)
+		if err != nil {
+			display.ErrorWithSupportCTA(err)
+			os.Exit(1)
+		}
+
+		state, err := confirmSteps(cl)
+		if err != nil {
+			display.ErrorWithSupportCTA(err)
+			os.Exit(1)
+		}
+		fmt.Printf("%d", state.Index)
+	},
+}
+
+const (
+	confirmRun  = "run"
+	confirmSkip = "skip"
+)
+
+// confirmSteps asks the user to confirm or skip dangerous steps until the current step doesn't need confirmation.
+// Steps the user neither confirms nor skips stay unconfirmed, so savvy doesn't put them on the command line.
+func confirmSteps(cl run.Client) (*run.State, error) {
+	for {
+		state, err := cl.CurrentState()
+		if err != nil {
+			return nil, err
+		}
+		if state.Command == "" || !state.NeedsConfirmation() {
+			return state, nil
+		}
+
+		choice := confirmRun
+		form := huh.NewForm(huh.NewGroup(
+			huh.NewSelect[string]().
+				Title(fmt.Sprintf("Step %d is flagged as dangerous: %s", state.Index+1, state.Danger)).
+				Description(state.CommandWithSetParams()).
+				Options(
+					huh.NewOption("Run it", confirmRun),
+					huh.NewOption("Skip it", confirmSkip),
+				).
+				Value(&choice),
+		)).WithTheme(huh.ThemeDracula())
+
+		// the shell reads the index from stdout, so draw the form on the terminal.
+		restore, err := drawOnTerminal()
+		if err != nil {
+			return nil, err
+		}
+		err = form.Run()
+		restore()
+		if errors.Is(err, huh.ErrUserAborted) {
+			return state, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if choice == confirmRun {
+			if err := cl.ConfirmCommand(state.Index); err != nil {
+				return nil, err
+			}
+			return state, nil
+		}
+		if err := cl.SkipCommand(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func init() {
+	InternalCmd.AddCommand(confirmCmd)
+}
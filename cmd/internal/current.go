@@ -26,10 +26,21 @@ var currentCmd = &cobra.Command{
 			return
 		}
 
+		if printIndex {
+			fmt.Printf("%d", state.Index)
+			return
+		}
+		if state.NeedsConfirmation() {
+			// dangerous steps aren't put on the command line until they are confirmed.
+			return
+		}
 		fmt.Printf("%s", state.CommandWithSetParams())
 	},
 }
 
+var printIndex bool
+
 func init() {
 	InternalCmd.AddCommand(currentCmd)
+	currentCmd.Flags().BoolVar(&printIndex, "index", false, "print the index of the current step instead of its command")
 }
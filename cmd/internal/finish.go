@@ -0,0 +1,46 @@
+package internal
+
+import (
+	"github.com/getsavvyinc/savvy-cli/display"
+	"github.com/getsavvyinc/savvy-cli/server/run"
+	"github.com/spf13/cobra"
+)
+
+// finishCmd represents the finish command
+var finishCmd = &cobra.Command{
+	Use:    "finish",
+	Hidden: true,
+	Short:  "Report the exit code of the command that ran last",
+	Long: `Finish reports the exit code of the command that ran last.
+  If the command ran a step that didn't pass its assertion, it prints how the step differs from what was expected.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		cl, err := run.NewDefaultClient(ctx)
+		if err != nil {
+			display.ErrorWithSupportCTA(err)
+			return
+		}
+
+		if err := cl.FinishCommand(exitCode, ""); err != nil {
+			display.ErrorWithSupportCTA(err)
+			return
+		}
+
+		state, err := cl.CurrentState()
+		if err != nil {
+			display.ErrorWithSupportCTA(err)
+			return
+		}
+		// flag the step right above the prompt, so it's clear which step to look into before moving on.
+		if state.Mismatch != nil {
+			display.ErrorMsg(state.Mismatch.String())
+		}
+	},
+}
+
+var exitCode int
+
+func init() {
+	InternalCmd.AddCommand(finishCmd)
+	finishCmd.Flags().IntVar(&exitCode, "exit-code", 0, "exit code of the command")
+}
@@ -0,0 +1,157 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/getsavvyinc/savvy-cli/display"
+	"github.com/getsavvyinc/savvy-cli/server/run"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// gotoCmd represents the goto command
+var gotoCmd = &cobra.Command{
+	Use:    "goto [step]",
+	Hidden: true,
+	Short:  "Go to a step of the runbook. Without a step, pick one from the list of steps",
+	Args:   cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		cl, err := run.NewDefaultClient(ctx)
+		if err != nil {
+			display.ErrorWithSupportCTA(err)
+			return
+		}
+
+		state, err := cl.CurrentState()
+		if err != nil {
+			display.ErrorWithSupportCTA(err)
+			os.Exit(1)
+		}
+
+		steps, err := cl.ListCommands()
+		if err != nil {
+			display.ErrorWithSupportCTA(err)
+			os.Exit(1)
+		}
+
+		var index int
+		if len(args) == 1 {
+			if index, err = parseStep(args[0], len(steps)); err != nil {
+				display.Error(err)
+				os.Exit(1)
+			}
+		} else if index, err = pickStep(steps, state.Index); errors.Is(err, huh.ErrUserAborted) {
+			// stay on the current step.
+			fmt.Printf("%d", state.Index)
+			return
+		} else if err != nil {
+			display.ErrorWithSupportCTA(err)
+			os.Exit(1)
+		}
+
+		updated, err := gotoCommand(ctx, cl, index)
+		if err != nil {
+			display.ErrorWithSupportCTA(err)
+			os.Exit(1)
+		}
+		fmt.Printf("%d", updated.Index)
+	},
+}
+
+func gotoCommand(ctx context.Context, cl run.Client, index int) (*run.State, error) {
+	if err := cl.GoToCommand(index); err != nil {
+		return nil, err
+	}
+	return cl.CurrentState()
+}
+
+var ErrInvalidStep = errors.New("invalid step")
+
+// parseStep parses a 1-based step number into the index of the step.
+func parseStep(s string, numSteps int) (int, error) {
+	step, err := strconv.Atoi(s)
+	if err != nil || step < 1 || step > numSteps {
+		return 0, fmt.Errorf("%w %q: must be a number between 1 and %d", ErrInvalidStep, s, numSteps)
+	}
+	return step - 1, nil
+}
+
+// pickStep lets the user pick a step and returns its index.
+func pickStep(steps []run.Step, current int) (int, error) {
+	if len(steps) == 0 {
+		return 0, errors.New("the runbook has no steps")
+	}
+
+	options := make([]huh.Option[int], 0, len(steps))
+	for _, step := range steps {
+		options = append(options, huh.NewOption(stepLabel(step), step.Index))
+	}
+
+	index := min(current, len(steps)-1)
+	pick := huh.NewSelect[int]().
+		Title("Go to step").
+		Description("Press / to filter the steps").
+		Options(options...).
+		Value(&index)
+
+	// the shell reads the new index from stdout, so draw the picker on the terminal.
+	restore, err := drawOnTerminal()
+	if err != nil {
+		return 0, err
+	}
+	defer restore()
+
+	if err := huh.NewForm(huh.NewGroup(pick)).WithTheme(huh.ThemeDracula()).Run(); err != nil {
+		return 0, err
+	}
+	return index, nil
+}
+
+// drawOnTerminal makes forms draw on the terminal when stdout is read by the shell.
+// The returned func restores stdout.
+func drawOnTerminal() (func(), error) {
+	if term.IsTerminal(int(os.Stdout.Fd())) {
+		return func() {}, nil
+	}
+
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	stdout := os.Stdout
+	os.Stdout = tty
+	return func() {
+		os.Stdout = stdout
+		tty.Close()
+	}, nil
+}
+
+// stepLabel describes a step on a single line e.g "2. Create the namespace: kubectl create namespace test".
+func stepLabel(step run.Step) string {
+	command, _, multiline := strings.Cut(step.Command, "\n")
+	if multiline {
+		command += " …"
+	}
+
+	label := fmt.Sprintf("%d. %s", step.Index+1, command)
+	if description := strings.TrimSpace(step.Description); description != "" {
+		description, _, _ = strings.Cut(description, "\n")
+		label = fmt.Sprintf("%d. %s: %s", step.Index+1, description, command)
+	}
+	if step.Danger != "" {
+		label += " (dangerous: " + step.Danger + ")"
+	}
+	return label
+}
+
+func init() {
+	InternalCmd.AddCommand(gotoCmd)
+}
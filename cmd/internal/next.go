@@ -23,6 +23,13 @@ var nextCmd = &cobra.Command{
 			return
 		}
 
+		if executedCommand != "" {
+			if err := cl.StartCommand(executedCommand); err != nil {
+				display.ErrorWithSupportCTA(err)
+				os.Exit(1)
+			}
+		}
+
 		state, err := cl.CurrentState()
 		if err != nil {
 			display.ErrorWithSupportCTA(err)
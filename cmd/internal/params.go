@@ -0,0 +1,234 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/getsavvyinc/savvy-cli/danger"
+	"github.com/getsavvyinc/savvy-cli/display"
+	"github.com/getsavvyinc/savvy-cli/param"
+)
+
+// PromptParams asks the user for the values of params and returns them keyed by Param.Key.
+//
+// Params whose choices come from a command that uses other params are asked for once those params are set, so that
+// the command runs with their values. known holds the values that are already set.
+func PromptParams(ctx context.Context, title, description string, params []param.Param, known map[string]string, t *huh.Theme) (map[string]string, error) {
+	classifier := LoadClassifier()
+	confirm := func(p param.Param) bool {
+		return confirmChoicesCommand(p, classifier, t)
+	}
+
+	resolved := make(map[string]string, len(known))
+	for k, v := range known {
+		resolved[k] = v
+	}
+
+	values := map[string]string{}
+	remaining := params
+	for len(remaining) > 0 {
+		var ready, later []param.Param
+		for _, p := range remaining {
+			if dependsOnUnset(p, remaining) {
+				later = append(later, p)
+				continue
+			}
+			if p.Type == param.TypeCommand {
+				p.Command = param.Replace(p.Command, resolved)
+			}
+			ready = append(ready, p)
+		}
+		// params that depend on each other can't be ordered, so ask for all of them.
+		if len(ready) == 0 {
+			ready, later = later, nil
+		}
+
+		fields := ParamFields(ctx, ready, confirm)
+		fs := []huh.Field{huh.NewNote().Title(title).Description(description)}
+		for _, p := range ready {
+			fs = append(fs, fields[p.Key()])
+		}
+		if err := huh.NewForm(huh.NewGroup(fs...).Title(title).WithTheme(t)).Run(); err != nil {
+			return nil, err
+		}
+
+		for k, v := range ParamValues(fs) {
+			values[k] = v
+			resolved[k] = v
+		}
+		remaining = later
+	}
+	return values, nil
+}
+
+// dependsOnUnset reports whether p is a cmd param whose command uses one of the params that are still unset.
+func dependsOnUnset(p param.Param, unset []param.Param) bool {
+	if p.Type != param.TypeCommand {
+		return false
+	}
+	for _, nested := range param.Parse(p.Command) {
+		for _, u := range unset {
+			if u.Name == nested.Name && u.Name != p.Name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ParamFields returns a field for each param, keyed by Param.Key.
+// Enum and cmd params are rendered as a select, secret params as a password input and other params as an input that
+// validates the value.
+// The command of a cmd param only runs if confirm returns true. Otherwise the user types the value instead.
+func ParamFields(ctx context.Context, params []param.Param, confirm func(p param.Param) bool) map[string]huh.Field {
+	fields := map[string]huh.Field{}
+
+	for _, p := range params {
+		if _, ok := fields[p.Key()]; ok {
+			continue
+		}
+		fields[p.Key()] = paramField(ctx, p, confirm)
+	}
+	return fields
+}
+
+func paramField(ctx context.Context, p param.Param, confirm func(p param.Param) bool) huh.Field {
+	title := "Set " + p.Key()
+	value := p.Default
+	description := paramDescription(p)
+
+	choices := p.Choices
+	if p.Type == param.TypeCommand {
+		var err error
+		if !confirm(p) {
+			// let the user type the value instead.
+			description = fmt.Sprintf("%s\n%s wasn't run", description, p.Command)
+		} else if choices, err = commandChoices(ctx, p.Command); err != nil {
+			// let the user type the value instead.
+			description = fmt.Sprintf("%s\n%s failed: %s", description, p.Command, err)
+		} else {
+			description = "Press / to filter the choices"
+			if p.Description != "" {
+				description = p.Description + ". " + description
+			}
+		}
+	}
+
+	if len(choices) > 0 {
+		return huh.NewSelect[string]().
+			Title(title).
+			Description(description).
+			Options(huh.NewOptions(choices...)...).
+			Value(&value).
+			Key(p.Key())
+	}
+
+	return huh.NewInput().
+		Title(title).
+		Description(description).
+		Value(&value).
+		Password(p.Secret).
+		Validate(p.Validate).
+		Key(p.Key())
+}
+
+// paramDescription returns the description of the param followed by a hint about the values it takes.
+func paramDescription(p param.Param) string {
+	hint := p.Hint()
+	switch {
+	case hint == "":
+		return p.Description
+	case p.Description == "":
+		return "Enter " + hint
+	default:
+		return fmt.Sprintf("%s (%s)", p.Description, hint)
+	}
+}
+
+// confirmChoicesCommand asks the user to confirm running the command that prints the choices of p.
+// The command comes from the runbook, so it never runs without the user's consent. Dangerous commands aren't run by default.
+func confirmChoicesCommand(p param.Param, classifier *danger.Classifier, t *huh.Theme) bool {
+	title := fmt.Sprintf("Run a command to list the choices for %s?", p.Key())
+	confirmed := true
+	if reason, ok := classifier.Classify(p.Command); ok {
+		title = fmt.Sprintf("The command that lists the choices for %s is flagged as dangerous: %s", p.Key(), reason)
+		confirmed = false
+	}
+
+	err := huh.NewForm(huh.NewGroup(
+		huh.NewConfirm().
+			Title(title).
+			Description(p.Command).
+			Affirmative("Run it").
+			Negative("Type the value instead").
+			Value(&confirmed),
+	)).WithTheme(t).Run()
+	return err == nil && confirmed
+}
+
+// LoadClassifier loads the user's rules for dangerous commands. Invalid rules fall back to the built-in rules.
+func LoadClassifier() *danger.Classifier {
+	classifier, err := danger.LoadClassifier()
+	if err != nil {
+		display.Error(err, "Only the built-in rules for dangerous commands apply")
+		classifier, _ = danger.NewClassifier(nil)
+	}
+	return classifier
+}
+
+// commandChoicesTimeout limits how long the command that prints the choices of a param can run.
+const commandChoicesTimeout = 10 * time.Second
+
+var ErrNoChoices = errors.New("no choices printed")
+
+// commandChoices runs command in a shell and returns the lines it printed.
+func commandChoices(ctx context.Context, command string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, commandChoicesTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	c := exec.CommandContext(ctx, "/bin/sh", "-c", command)
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	if err := c.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+
+	var choices []string
+	seen := map[string]bool{}
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || seen[line] {
+			continue
+		}
+		seen[line] = true
+		choices = append(choices, line)
+	}
+	if len(choices) == 0 {
+		return nil, ErrNoChoices
+	}
+	return choices, nil
+}
+
+// ParamValues returns the values entered in the param fields, keyed by Param.Key.
+func ParamValues(fs []huh.Field) map[string]string {
+	values := map[string]string{}
+	for _, f := range fs {
+		if f.GetKey() == "" {
+			continue
+		}
+		if v, ok := f.GetValue().(string); ok {
+			values[f.GetKey()] = v
+		}
+	}
+	return values
+}
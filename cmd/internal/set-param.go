@@ -1,9 +1,7 @@
 package internal
 
 import (
-	"context"
 	"os"
-	"strings"
 
 	"github.com/charmbracelet/huh"
 	"github.com/getsavvyinc/savvy-cli/display"
@@ -31,46 +29,17 @@ var subcommandCmd = &cobra.Command{
 		}
 
 		command := state.CommandWithSetParams()
-		params := param.Extract(command)
+		params := param.Parse(command)
 		// Exit early
 		if len(params) == 0 {
 			return
 		}
 
-		fields := ParamFields(ctx, params)
-
-		var fs []huh.Field
-		description := "Set parameters for the command"
-		note := huh.NewNote().Title(command).Description(description)
-		fs = append(fs, note)
-		for _, param := range params {
-			fs = append(fs, fields[param])
-		}
-
-		if len(fs) == 0 {
-			return
-		}
-
-		paramGroup := huh.NewGroup(fs...).Title(command).WithTheme(huh.ThemeDracula())
-
-		if err := huh.NewForm(paramGroup).Run(); err != nil {
+		newParams, err := PromptParams(ctx, command, "Set parameters for the command", params, state.Params, huh.ThemeDracula())
+		if err != nil {
 			display.ErrorWithSupportCTA(err)
 			os.Exit(1)
 		}
-
-		newParams := map[string]string{}
-		for _, f := range fs {
-			i, ok := f.(*huh.Input)
-			if !ok {
-				continue
-			}
-			strVal, ok := i.GetValue().(string)
-			if !ok {
-				continue
-			}
-			newParams[i.GetKey()] = strVal
-		}
-
 		if err := cl.SetParams(newParams); err != nil {
 			display.ErrorWithSupportCTA(err)
 			os.Exit(1)
@@ -84,29 +53,3 @@ var params []string
 func init() {
 	InternalCmd.AddCommand(subcommandCmd)
 }
-
-func ParamFields(ctx context.Context, params []string) map[string]huh.Field {
-	fields := map[string]huh.Field{}
-
-	for _, param := range params {
-		if _, ok := fields[param]; ok {
-			continue
-		}
-		title, desc := parseParam(param)
-		fields[param] = huh.NewInput().Title(title).Description(desc).Key(param)
-	}
-	return fields
-}
-
-const DefaultTitle = "Set Params"
-const DefaultDescription = ""
-
-func parseParam(param string) (string, string) {
-	if !strings.HasPrefix(param, "<") || !strings.HasSuffix(param, ">") {
-		return DefaultTitle, DefaultDescription
-	}
-
-	title := "Set " + param
-
-	return title, ""
-}
@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/getsavvyinc/savvy-cli/display"
+	"github.com/getsavvyinc/savvy-cli/server/run"
+	"github.com/spf13/cobra"
+)
+
+// skipCmd represents the skip command
+var skipCmd = &cobra.Command{
+	Use:    "skip",
+	Hidden: true,
+	Short:  "Skip the current step of the runbook without running it",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		cl, err := run.NewDefaultClient(ctx)
+		if err != nil {
+			display.ErrorWithSupportCTA(err)
+			return
+		}
+
+		updated, err := skipCommand(ctx, cl)
+		if err != nil {
+			display.ErrorWithSupportCTA(err)
+			os.Exit(1)
+		}
+		fmt.Printf("%d", updated.Index)
+	},
+}
+
+func skipCommand(ctx context.Context, cl run.Client) (*run.State, error) {
+	if err := cl.SkipCommand(); err != nil {
+		return nil, err
+	}
+	return cl.CurrentState()
+}
+
+func init() {
+	InternalCmd.AddCommand(skipCmd)
+}
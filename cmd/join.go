@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/getsavvyinc/savvy-cli/display"
+	"github.com/getsavvyinc/savvy-cli/server"
+	"github.com/getsavvyinc/savvy-cli/server/mode"
+	"github.com/getsavvyinc/savvy-cli/shell"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var joinTerminalName string
+
+// recordJoinCmd records the commands of another terminal to a running recording session.
+var recordJoinCmd = &cobra.Command{
+	Use:   "join [socket]",
+	Short: "Record commands in this terminal to a running recording session",
+	Long: `Record the commands of this terminal to a recording session started in another terminal.
+
+Steps are labeled with the terminal they ran in so that the runbook can group or interleave them.
+Use as: eval "$(savvy record join)"`,
+	Example: `  eval "$(savvy record join)"
+  eval "$(savvy record join --name db-shell)"`,
+	Args: cobra.MaximumNArgs(1),
+	PreRun: func(_ *cobra.Command, _ []string) {
+		checker := shell.NewSetupChecker()
+		if err := checker.CheckSetup(); err != nil {
+			display.Error(err)
+			os.Exit(1)
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if term.IsTerminal(int(os.Stdout.Fd())) {
+			display.Info(`To record commands in this terminal, run: eval "$(savvy record join)"`)
+			return
+		}
+
+		if os.Getenv("SAVVY_CONTEXT") != "" {
+			display.ErrorMsg("This shell is already recording or running a runbook")
+			os.Exit(1)
+		}
+
+		socketPath, err := sessionToJoin(args)
+		if err != nil {
+			display.Error(err)
+			os.Exit(1)
+		}
+
+		terminal := joinTerminalName
+		if terminal == "" {
+			terminal = defaultTerminalLabel()
+		}
+
+		snippet, err := shell.New(socketPath).JoinSnippet(terminal)
+		if err != nil {
+			display.Error(err)
+			os.Exit(1)
+		}
+
+		fmt.Print(snippet)
+		// stdout is eval'd by the shell, so talk to the user on stderr.
+		fmt.Fprintf(os.Stderr, "Recording commands in this terminal as %q. Recording stops when the session ends.\n", terminal)
+	},
+}
+
+// sessionToJoin returns the socket path of the recording session to join.
+func sessionToJoin(args []string) (string, error) {
+	if len(args) == 1 {
+		if _, err := os.Stat(args[0]); err != nil {
+			return "", fmt.Errorf("no recording session at %s: %w", args[0], err)
+		}
+		return args[0], nil
+	}
+
+	sessions := server.ActiveSessions(mode.Record)
+	switch len(sessions) {
+	case 0:
+		return "", errors.New("no recording session is running. Start one with 'savvy record'")
+	case 1:
+		return sessions[0], nil
+	default:
+		return "", fmt.Errorf("multiple recording sessions are running. Join one with: eval \"$(savvy record join <socket>)\"\n  %s", strings.Join(sessions, "\n  "))
+	}
+}
+
+// defaultTerminalLabel returns a label like host:pts/3 for the current terminal.
+func defaultTerminalLabel() string {
+	host, _ := os.Hostname()
+	host, _, _ = strings.Cut(host, ".")
+
+	// stdout is captured by the shell, but stdin is still the terminal.
+	c := exec.Command("tty")
+	c.Stdin = os.Stdin
+	out, err := c.Output()
+	tty := strings.TrimPrefix(strings.TrimSpace(string(out)), "/dev/")
+	if err != nil || tty == "" {
+		tty = fmt.Sprintf("%d", os.Getppid())
+	}
+
+	if host == "" {
+		return tty
+	}
+	return host + ":" + tty
+}
+
+func init() {
+	recordJoinCmd.Flags().StringVar(&joinTerminalName, "name", "", "Label for the steps recorded in this terminal. Defaults to the host and tty")
+	recordCmd.AddCommand(recordJoinCmd)
+}
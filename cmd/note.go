@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+
+	"github.com/getsavvyinc/savvy-cli/display"
+	"github.com/getsavvyinc/savvy-cli/server"
+	"github.com/spf13/cobra"
+)
+
+// noteCmd represents the note command
+var noteCmd = &cobra.Command{
+	Use:   "note <text>",
+	Short: "Add a note between recorded commands",
+	Long:  `Add a markdown note between recorded commands e.g to explain what you verified at this point of the workflow.`,
+	Example: `
+  savvy record note "verified the pods are healthy here"
+  `,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		note := strings.TrimSpace(strings.Join(args, " "))
+		if note == "" {
+			display.ErrorMsg("note is empty")
+			return
+		}
+
+		cl, err := server.NewDefaultClient(context.Background())
+		if err != nil {
+			display.Error(err)
+			return
+		}
+
+		if err := cl.SendNote(note); err != nil {
+			display.ErrorWithSupportCTA(err)
+			return
+		}
+		display.Info("Note recorded")
+	},
+}
+
+func init() {
+	recordCmd.AddCommand(noteCmd)
+}
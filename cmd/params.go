@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/getsavvyinc/savvy-cli/display"
+	"github.com/getsavvyinc/savvy-cli/param"
+	"github.com/getsavvyinc/savvy-cli/server/run"
+	"github.com/getsavvyinc/savvy-cli/slice"
+)
+
+// runbookParams returns the params used by the steps of the runbook, in the order they're first used.
+func runbookParams(commands []*run.RunCommand) []param.Param {
+	var all strings.Builder
+	for _, cmd := range commands {
+		all.WriteString(cmd.Command + "\n")
+	}
+	return param.Parse(all.String())
+}
+
+// presetParams returns the param values set with --param, --param-file and SAVVY_PARAM_<NAME> environment variables.
+// Values must be valid for the type of the param e.g a port.
+func presetParams(params []param.Param) (map[string]string, error) {
+	var fromFile map[string]string
+	if paramFile != "" {
+		var err error
+		if fromFile, err = param.ReadFile(paramFile); err != nil {
+			return nil, fmt.Errorf("failed to read params: %w", err)
+		}
+	}
+
+	fromFlags, err := param.ParseAssignments(paramFlags)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --param: %w", err)
+	}
+
+	keys := slice.Map(params, param.Param.Key)
+	values := param.Merge(param.FromEnv(keys, os.LookupEnv), fromFile, fromFlags)
+	for _, p := range params {
+		if v, ok := values[p.Key()]; ok {
+			if err := p.Validate(v); err != nil {
+				return nil, fmt.Errorf("invalid value for the param %s: %w", p.Key(), err)
+			}
+		}
+	}
+	return values, nil
+}
+
+// seedParams sets the preset param values in the run session, so that users are only prompted for the remaining params.
+// Preset values override the values restored from the run that is resumed.
+func seedParams(ctx context.Context, rsrv *run.RunServer) error {
+	params := runbookParams(rsrv.Commands())
+	values, err := presetParams(params)
+	if err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	keys := slice.Map(params, param.Param.Key)
+	for key := range values {
+		if !slice.Has(keys, key) {
+			display.Infof("The runbook doesn't use the param %s", key)
+		}
+	}
+
+	cl, err := run.NewClient(ctx, rsrv.SocketPath())
+	if err != nil {
+		return err
+	}
+	if err := cl.OverrideParams(values); err != nil {
+		return fmt.Errorf("failed to set params: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/getsavvyinc/savvy-cli/display"
+	"github.com/getsavvyinc/savvy-cli/server"
+	"github.com/spf13/cobra"
+)
+
+// pauseCmd represents the pause command
+var pauseCmd = &cobra.Command{
+	Use:   "pause",
+	Short: "Pause the current recording session",
+	Long: `Pause the current recording session.
+
+  Commands you run while the session is paused are not recorded. Run 'savvy record resume' to continue recording.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		cl, err := server.NewDefaultClient(context.Background())
+		if err != nil {
+			display.Error(err)
+			return
+		}
+
+		if err := cl.SendPause(); err != nil {
+			display.ErrorWithSupportCTA(err)
+			return
+		}
+		display.Info("Recording paused. Run 'savvy record resume' to continue recording.")
+	},
+}
+
+// resumeCmd represents the resume command
+var resumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Resume a paused recording session",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		cl, err := server.NewDefaultClient(context.Background())
+		if err != nil {
+			display.Error(err)
+			return
+		}
+
+		if err := cl.SendResume(); err != nil {
+			display.ErrorWithSupportCTA(err)
+			return
+		}
+		display.Info("Recording resumed.")
+	},
+}
+
+func init() {
+	recordCmd.AddCommand(pauseCmd)
+	recordCmd.AddCommand(resumeCmd)
+}
@@ -32,6 +32,10 @@ var recordCmd = &cobra.Command{
 
   Type 'exit' to exit the sub shell and view the runbook.`,
 	PreRun: func(_ *cobra.Command, _ []string) {
+		if isHeadlessRecording() {
+			// headless recordings don't spawn a shell.
+			return
+		}
 		checker := shell.NewSetupChecker()
 		if err := checker.CheckSetup(); err != nil {
 			display.Error(err)
@@ -46,44 +50,100 @@ var programOutput = termenv.NewOutput(os.Stdout, termenv.WithColorCache(true))
 func runRecordCmd(cmd *cobra.Command, _ []string) {
 	ctx := cmd.Context()
 
-	recordedCommands, err := startRecording(ctx)
+	if recordHere {
+		runRecordHere()
+		return
+	}
+
+	rules, err := redact.LoadRules()
+	if err != nil {
+		display.Error(err)
+		os.Exit(1)
+	}
+
+	if isHeadlessRecording() {
+		runHeadlessRecording(ctx, rules)
+		return
+	}
+
+	ignorePolicy, err := server.LoadIgnorePolicy()
+	if err != nil {
+		display.Error(err)
+		os.Exit(1)
+	}
+
+	journal, err := server.NewJournal()
+	if err != nil {
+		display.ErrorWithSupportCTA(err)
+		os.Exit(1)
+	}
+
+	recordedCommands, err := startRecording(ctx,
+		server.WithIgnoreErrors(ignoreErrors),
+		server.WithJournal(journal),
+		server.WithFileFilter(rules.AllowsFile),
+		server.WithIgnorePolicy(ignorePolicy),
+		server.WithContextSnapshots(!noContext),
+	)
 	if errors.Is(err, server.ErrAbortRecording) {
+		journal.Remove()
 		display.Info("Recording aborted")
 		return
 	}
 
 	if err != nil {
+		journal.Remove()
 		display.ErrorWithSupportCTA(err)
 		os.Exit(1)
 	}
 
 	if len(recordedCommands) == 0 {
+		journal.Remove()
 		display.Error(errors.New("No commands were recorded"))
 		return
 	}
 
-	redactedCommands, err := redact.Commands(recordedCommands)
-	if err != nil {
+	if err := exportRecordedCommands(ctx, recordedCommands, rules); err != nil {
 		display.ErrorWithSupportCTA(err)
-		return
+		display.Info("Your recording was saved. Run 'savvy record recover' to try again.")
+		os.Exit(1)
+	}
+	journal.Remove()
+}
+
+// redactCommands applies the redaction rules to the recorded commands before letting the user redact them.
+// If --no-interactive-redact is set, detected secrets are replaced automatically instead.
+func redactCommands(recordedCommands []*server.RecordedCommand, rules *redact.Rules) ([]*server.RecordedCommand, error) {
+	recordedCommands = rules.Apply(recordedCommands)
+	if noInteractiveRedact {
+		return redact.Auto(recordedCommands), nil
+	}
+	return redact.Commands(recordedCommands)
+}
+
+// exportRecordedCommands redacts the recorded commands before exporting them.
+func exportRecordedCommands(ctx context.Context, recordedCommands []*server.RecordedCommand, rules *redact.Rules) error {
+	redactedCommands, err := redactCommands(recordedCommands, rules)
+	if err != nil {
+		return err
+	}
+
+	if len(redactedCommands) == 0 {
+		return errors.New("all recorded commands were redacted")
 	}
 
 	links, err := getLinks(ctx)
 	if err != nil {
-		fmt.Errorf("failed to get links from Savvy's Chrome Extension: %w", err)
+		err = fmt.Errorf("failed to get links from Savvy's Chrome Extension: %w", err)
 		display.ErrorWithSupportCTA(err)
 	}
 
 	exporter := export.NewExporter(redactedCommands, links)
-	if err := exporter.Export(ctx); err != nil {
-		display.ErrorWithSupportCTA(err)
-		os.Exit(1)
-	}
+	return exporter.Export(ctx)
 }
 
-func startRecording(ctx context.Context) ([]*server.RecordedCommand, error) {
-	// TODO: Make this unique for each invokation
-	ss, err := server.NewUnixSocketServerWithDefaultPath(server.WithIgnoreErrors(ignoreErrors))
+func startRecording(ctx context.Context, opts ...server.Option) ([]*server.RecordedCommand, error) {
+	ss, err := server.NewUnixSocketServerWithSessionPath(opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to start recording: %w", err)
 	}
@@ -100,6 +160,7 @@ func startRecording(ctx context.Context) ([]*server.RecordedCommand, error) {
 
 	// Create arbitrary command.
 	sh := shell.New(ss.SocketPath())
+	defer sh.Close()
 
 	c, err := sh.Spawn(ctx)
 	if err != nil {
@@ -155,11 +216,13 @@ func startRecording(ctx context.Context) ([]*server.RecordedCommand, error) {
 
 	go func() {
 		defer wg.Done()
-		io.Copy(ptmx, cancelReader)
+		// The server sees the keys the user types to record the lines entered in repls e.g psql.
+		io.Copy(io.MultiWriter(ss.InputWriter(), ptmx), cancelReader)
 	}()
 
 	// io.Copy blocks till ptmx is closed.
-	io.Copy(os.Stdout, ptmx)
+	// The server attributes the output to the step that is currently executing.
+	io.Copy(io.MultiWriter(os.Stdout, ss), ptmx)
 
 	// cleanup
 	//// cancel ctx and wait for the underlying shell command to finish
@@ -174,9 +237,18 @@ func startRecording(ctx context.Context) ([]*server.RecordedCommand, error) {
 }
 
 var ignoreErrors bool
+var noInteractiveRedact bool
+var noContext bool
 
 func init() {
 	rootCmd.AddCommand(recordCmd)
 	// add a boolean flag
 	recordCmd.Flags().BoolVar(&ignoreErrors, "ignore-errors", false, "Ignore commands that return an error when recording commands")
+	recordCmd.Flags().StringVar(&fromScript, "from-script", "", "Record the commands in a shell script instead of starting a shell")
+	recordCmd.Flags().StringVar(&fromTypescript, "from-typescript", "", "Record the commands, and their output, in a script(1) typescript or asciinema recording instead of starting a shell")
+	recordCmd.Flags().StringVar(&promptRegex, "prompt-regex", "", "Regular expression that matches the shell prompt in --from-typescript recordings")
+	recordCmd.Flags().BoolVar(&noContext, "no-context", false, "Don't record the environment e.g git branch, kube context or AWS profile each command runs against")
+	recordCmd.Flags().BoolVar(&recordHere, "here", false, `Record commands in the current shell instead of starting a new one. Use as: eval "$(savvy record --here)"`)
+	recordCmd.MarkFlagsMutuallyExclusive("here", "from-script", "from-typescript")
+	recordCmd.PersistentFlags().BoolVar(&noInteractiveRedact, "no-interactive-redact", false, "Redact commands using redaction rules and secret detection only, without prompting")
 }
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/getsavvyinc/savvy-cli/display"
+	"github.com/getsavvyinc/savvy-cli/redact"
+	"github.com/getsavvyinc/savvy-cli/server"
+	"github.com/spf13/cobra"
+)
+
+// recoverCmd represents the recover command
+var recoverCmd = &cobra.Command{
+	Use:   "recover",
+	Short: "Recover a recording session that was interrupted",
+	Long: `Recover lists recording sessions that were interrupted before they were exported.
+
+  Select a session to redact and export the commands recorded in it.`,
+	Args: cobra.NoArgs,
+	Run:  runRecoverCmd,
+}
+
+func init() {
+	recordCmd.AddCommand(recoverCmd)
+}
+
+func runRecoverCmd(cmd *cobra.Command, _ []string) {
+	ctx := cmd.Context()
+	logger := loggerFromCtx(ctx).With("command", "recover")
+
+	rules, err := redact.LoadRules()
+	if err != nil {
+		display.Error(err)
+		os.Exit(1)
+	}
+
+	sessions, err := server.UnfinishedSessions()
+	if err != nil {
+		display.ErrorWithSupportCTA(err)
+		os.Exit(1)
+	}
+
+	if len(sessions) == 0 {
+		display.Info("There are no recording sessions to recover")
+		return
+	}
+
+	var options []huh.Option[*server.UnfinishedSession]
+	for _, session := range sessions {
+		label := fmt.Sprintf("%s (%d steps)", session.StartedAt.Local().Format(time.DateTime), session.Steps)
+		options = append(options, huh.NewOption(label, session))
+	}
+
+	var selected *server.UnfinishedSession
+	if err := huh.NewSelect[*server.UnfinishedSession]().
+		Title("Select a recording session to recover").
+		Options(options...).
+		Value(&selected).
+		Run(); err != nil {
+		logger.Debug("failed to run form", "error", err)
+		return
+	}
+
+	recordedCommands, err := selected.Commands()
+	if err != nil {
+		display.ErrorWithSupportCTA(err)
+		os.Exit(1)
+	}
+
+	if len(recordedCommands) == 0 {
+		display.Error(errors.New("No commands were recorded"))
+		selected.Journal.Remove()
+		return
+	}
+
+	if err := exportRecordedCommands(ctx, recordedCommands, rules); err != nil {
+		display.ErrorWithSupportCTA(err)
+		os.Exit(1)
+	}
+	selected.Journal.Remove()
+}
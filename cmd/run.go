@@ -17,6 +17,7 @@ import (
 	"github.com/creack/pty"
 	"github.com/getsavvyinc/savvy-cli/client"
 	"github.com/getsavvyinc/savvy-cli/client/local"
+	"github.com/getsavvyinc/savvy-cli/cmd/internal"
 	"github.com/getsavvyinc/savvy-cli/display"
 	"github.com/getsavvyinc/savvy-cli/server/run"
 	"github.com/getsavvyinc/savvy-cli/shell"
@@ -35,6 +36,18 @@ var runCmd = &cobra.Command{
 
   # Run a specific runbook
   savvy run rb-runbookID
+
+  # Run all steps of a runbook without prompting e.g in CI or cron
+  savvy run rb-runbookID --exec
+
+  # Set the value of the <db-host> param instead of being prompted for it
+  savvy run rb-runbookID --param db-host=localhost
+
+  # Set params from a yaml file that maps param names to values
+  savvy run rb-runbookID --param-file params.yaml
+
+  # Pick up a run that was interrupted where it left off
+  savvy run --resume
   `,
 	Long: `
   Run allows users to select any runbook and run it.
@@ -44,15 +57,67 @@ var runCmd = &cobra.Command{
   If you provide a runbook ID, savvy run will run that specific runbook.
 
   Run automatically steps though the runbook for you, there's no need manually copy paste individual commands.
+  Press ctrl+x s to skip the current step, or ctrl+x g to pick the step to go to from a list you can filter.
+
+  With --exec, savvy run runs every step for you in a child shell and streams its output.
+  It stops at the first step that fails, unless --continue-on-error is set, and exits with that step's exit code.
+
+  Params e.g <db-host> are set with --param, --param-file or SAVVY_PARAM_<NAME> environment variables e.g SAVVY_PARAM_DB_HOST.
+  --param takes precedence over --param-file, which takes precedence over environment variables.
+  You're only prompted for the params that are still unset.
+  Params can declare a type, a default and a description e.g <port:port=5432 # Port the database listens on>, <env:enum(dev|prod)>
+  or <tag:regex(v[0-9]+)>. Values of secret params e.g <token!> are hidden while you type them.
+  The choices of a param can come from a command e.g <pod:cmd(kubectl get pods -n <namespace> -o name)>: each line it prints is a choice.
+
+  A step can set a param for later steps from its output with a trailing # savvy:capture <name>[=regex] comment
+  e.g kubectl create namespace test-$RANDOM -o name # savvy:capture namespace=namespace/(.+).
+  Without a regex, the last line of the output is captured.
+
+  Steps can assert what they're expected to do with an assert field e.g
+  {"command": "kubectl rollout status deploy/api", "assert": {"contains": "successfully rolled out", "timeout": "5m"}}.
+  An assertion can expect an exit_code (0 by default), output that contains some text or matches a regex, and a timeout.
+  Steps that don't pass their assertion are flagged above the prompt. With --exec, they fail the run, and steps that run
+  past their timeout are stopped.
+
+  The progress of every run is saved, except for secret params. If a run is interrupted, savvy run --resume lists the
+  unfinished runs and resumes the one you pick at the step it stopped at, with the params that were set.
+
+  Every command that runs during a run is logged to an audit log with who ran it, when, the params it used, whether it
+  matched the step or was edited, and its exit code. Secret params are redacted. Set --log-output to log the output of
+  commands too. Use savvy run log to list and view past runs.
+
+  Steps that look dangerous e.g rm -rf, kubectl delete, DROP TABLE or terraform destroy must be confirmed before they
+  run. With --exec, you're asked before a dangerous step runs, and the run stops if there is no terminal to ask in,
+  unless --allow-dangerous is set. Add your own rules to ~/.config/savvy/danger.yaml e.g
+
+    rules:
+      - pattern: '--namespace prod'
+        reason: changes production
+    allow:
+      - '^kubectl delete pod '
   `,
 	Run:  savvyRun,
 	Args: cobra.MaximumNArgs(1),
 }
 
 var localFlag bool
+var execFlag bool
+var continueOnErrorFlag bool
+var paramFlags []string
+var paramFile string
+var resumeFlag bool
+var logOutputFlag bool
+var allowDangerousFlag bool
 
 func init() {
 	runCmd.Flags().BoolVarP(&localFlag, "local", "l", false, "Use locally saved runbooks instead of fetching from the server")
+	runCmd.Flags().BoolVar(&execFlag, "exec", false, "Run all steps without prompting and exit with the exit code of the first failed step")
+	runCmd.Flags().BoolVar(&continueOnErrorFlag, "continue-on-error", false, "With --exec, keep running the remaining steps after a step fails")
+	runCmd.Flags().StringArrayVar(&paramFlags, "param", nil, "Set a param of the runbook as name=value. Can be repeated")
+	runCmd.Flags().StringVar(&paramFile, "param-file", "", "Set the params of the runbook from a yaml file of name: value pairs")
+	runCmd.Flags().BoolVar(&resumeFlag, "resume", false, "Resume a run that was interrupted before its last step")
+	runCmd.Flags().BoolVar(&allowDangerousFlag, "allow-dangerous", false, "With --exec, run steps flagged as dangerous without asking for confirmation")
+	runCmd.Flags().BoolVar(&logOutputFlag, "log-output", false, "Include the output of commands in the audit log of the run")
 	rootCmd.AddCommand(runCmd)
 }
 
@@ -60,6 +125,20 @@ func savvyRun(cmd *cobra.Command, args []string) {
 	ctx := cmd.Context()
 	logger := loggerFromCtx(ctx).With("command", "run")
 
+	var saved *run.SavedRun
+	if resumeFlag {
+		var err error
+		if saved, err = selectUnfinishedRun(args); err != nil {
+			display.ErrorWithSupportCTA(err)
+			os.Exit(1)
+		}
+		if saved == nil {
+			return
+		}
+		localFlag = saved.Local
+		args = []string{saved.Runbook.RunbookID}
+	}
+
 	var cl client.RunbookClient
 	if localFlag {
 		cl = local.New()
@@ -91,12 +170,58 @@ func savvyRun(cmd *cobra.Command, args []string) {
 	}
 
 	rb, err := fetchRunbook(ctx, cl, runbookID)
+	if err != nil && saved != nil {
+		logger.Debug("failed to fetch runbook", "runbook_id", runbookID, "error", err)
+		display.Info("Failed to fetch the runbook. Resuming with the runbook as it was when the run started.")
+		rb, err = saved.Runbook, nil
+	}
 	if err != nil {
 		logger.Error("failed to fetch runbook", "runbook_id", runbookID, "error", err)
+		if execFlag {
+			os.Exit(1)
+		}
 		return
 	}
 
-	if err := runRunbook(ctx, rb); err != nil {
+	if saved == nil {
+		saved = run.NewSavedRun(rb, localFlag)
+	} else {
+		if saved.RunbookChanged(rb) {
+			display.Infof("Warning: %s changed since the run started. Step %d may not be the step you stopped at.", rb.Title, saved.Index+1)
+		}
+		display.Infof("Resuming %s at step %d", rb.Title, saved.Index+1)
+	}
+
+	mode := "interactive"
+	if execFlag {
+		mode = "exec"
+	}
+	audit, err := run.NewAuditLog(saved.RunID, logOutputFlag)
+	if err != nil {
+		display.Error(err, "The commands of this run won't be logged")
+	}
+	if err := audit.RunStarted(rb, mode, resumeFlag); err != nil {
+		logger.Debug("failed to write audit log", "error", err)
+	}
+	// endAudit must run before savvy exits.
+	endAudit := func() {
+		audit.RunEnded()
+		audit.Close()
+	}
+	opts := []run.Option{run.WithSavedRun(saved), run.WithAuditLog(audit)}
+
+	if execFlag {
+		exitCode, err := execRunbook(ctx, rb, opts...)
+		endAudit()
+		if err != nil {
+			display.ErrorWithSupportCTA(fmt.Errorf("failed to run runbook %s: %w", rb.Title, err))
+			os.Exit(1)
+		}
+		os.Exit(exitCode)
+	}
+
+	defer endAudit()
+	if err := runRunbook(ctx, rb, opts...); err != nil {
 		display.ErrorWithSupportCTA(
 			fmt.Errorf("failed to run runbook %s: %w", rb.Title, err),
 		)
@@ -104,11 +229,12 @@ func savvyRun(cmd *cobra.Command, args []string) {
 	}
 }
 
-func runRunbook(ctx context.Context, runbook *client.Runbook) error {
+func runRunbook(ctx context.Context, runbook *client.Runbook, opts ...run.Option) error {
 	ctx, cancelCtx := context.WithCancel(ctx)
 	defer cancelCtx()
 
-	rsrv, err := run.NewServerWithDefaultSocketPath(runbook)
+	opts = append(opts, run.WithClassifier(internal.LoadClassifier()))
+	rsrv, err := run.NewServerWithSessionSocketPath(runbook, opts...)
 	if errors.Is(err, run.ErrAbortRun) {
 		display.Info("Run aborted")
 		return nil
@@ -126,7 +252,12 @@ func runRunbook(ctx context.Context, runbook *client.Runbook) error {
 		// os.Exit(1)
 	}()
 
+	if err := seedParams(ctx, rsrv); err != nil {
+		return err
+	}
+
 	sh := shell.New(rsrv.SocketPath())
+	defer sh.Close()
 
 	c, err := sh.SpawnRunbookRunner(ctx, runbook)
 	if err != nil {
@@ -186,7 +317,8 @@ func runRunbook(ctx context.Context, runbook *client.Runbook) error {
 	}()
 
 	// io.Copy blocks till ptmx is closed.
-	io.Copy(os.Stdout, ptmx)
+	// The run server sees the output too, so that steps can capture values from it.
+	io.Copy(io.MultiWriter(os.Stdout, rsrv), ptmx)
 
 	// cleanup
 	//// cancel ctx and wait for the underlying shell command to finish
@@ -199,6 +331,41 @@ func runRunbook(ctx context.Context, runbook *client.Runbook) error {
 	return nil
 }
 
+// selectUnfinishedRun lets the user pick the run to resume. Only runs of the runbook in args are listed, if there is one.
+// It returns nil if there is nothing to resume.
+func selectUnfinishedRun(args []string) (*run.SavedRun, error) {
+	runs, err := run.UnfinishedRuns()
+	if err != nil {
+		return nil, err
+	}
+
+	var options []huh.Option[*run.SavedRun]
+	for _, r := range runs {
+		if len(args) == 1 && r.Runbook.RunbookID != args[0] {
+			continue
+		}
+		options = append(options, huh.NewOption(r.Label(), r))
+	}
+
+	if len(options) == 0 {
+		display.Info("There are no unfinished runs to resume")
+		return nil, nil
+	}
+
+	var selected *run.SavedRun
+	if err := huh.NewSelect[*run.SavedRun]().
+		Title("Select a run to resume").
+		Options(options...).
+		Value(&selected).
+		Run(); err != nil {
+		if errors.Is(err, huh.ErrUserAborted) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return selected, nil
+}
+
 func fetchRunbook(ctx context.Context, cl client.RunbookClient, runbookID string) (*client.Runbook, error) {
 	logger := loggerFromCtx(ctx).With("command", "run", "method", "fetchRunbook")
 	var rb *client.Runbook
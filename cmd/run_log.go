@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/getsavvyinc/savvy-cli/display"
+	"github.com/getsavvyinc/savvy-cli/server/run"
+	"github.com/spf13/cobra"
+)
+
+// runLogCmd represents the run log command
+var runLogCmd = &cobra.Command{
+	Use:   "log [runID]",
+	Short: "List and view the audit logs of past runs",
+	Long: `Log shows the audit log of a run: who ran the runbook, every command that ran with its params,
+  whether it matched the step or was edited, and its exit code.
+
+  Without a run ID, log lists past runs so you can pick one.`,
+	Example: `
+  # Pick a past run and view its audit log
+  savvy run log
+
+  # Print the audit log of a run as JSON lines
+  savvy run log run-1234 --json
+  `,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runLogRun,
+}
+
+var runLogJSONFlag bool
+
+func init() {
+	runLogCmd.Flags().BoolVar(&runLogJSONFlag, "json", false, "Print the audit log as JSON lines")
+	runCmd.AddCommand(runLogCmd)
+}
+
+func runLogRun(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	logger := loggerFromCtx(ctx).With("command", "run log")
+
+	var runID string
+	if len(args) == 1 {
+		runID = args[0]
+	} else {
+		var err error
+		if runID, err = selectAuditedRun(); err != nil {
+			if !errors.Is(err, huh.ErrUserAborted) {
+				display.ErrorWithSupportCTA(err)
+				os.Exit(1)
+			}
+			logger.Debug("failed to run form", "error", err)
+			return
+		}
+		if runID == "" {
+			return
+		}
+	}
+
+	entries, err := run.ReadAuditLog(runID)
+	if err != nil {
+		display.Error(err)
+		os.Exit(1)
+	}
+
+	if runLogJSONFlag {
+		enc := json.NewEncoder(os.Stdout)
+		for _, entry := range entries {
+			enc.Encode(entry)
+		}
+		return
+	}
+	printAuditLog(os.Stdout, entries)
+}
+
+// selectAuditedRun lets the user pick a past run and returns its ID. It returns an empty ID if there are no runs.
+func selectAuditedRun() (string, error) {
+	runs, err := run.AuditedRuns()
+	if err != nil {
+		return "", err
+	}
+
+	if len(runs) == 0 {
+		display.Info("There are no runs to show")
+		return "", nil
+	}
+
+	var options []huh.Option[string]
+	for _, r := range runs {
+		label := fmt.Sprintf("%s %s by %s (%d commands", r.StartedAt.Local().Format(time.DateTime), r.Title, r.User, r.Commands)
+		if r.Failed > 0 {
+			label += fmt.Sprintf(", %d failed", r.Failed)
+		}
+		if !r.Ended {
+			label += ", not finished"
+		}
+		options = append(options, huh.NewOption(label+")", r.RunID))
+	}
+
+	var runID string
+	err = huh.NewSelect[string]().
+		Title("Select a run").
+		Options(options...).
+		Value(&runID).
+		Run()
+	return runID, err
+}
+
+func printAuditLog(w io.Writer, entries []run.AuditEntry) {
+	for _, entry := range entries {
+		at := entry.Time.Local().Format(time.DateTime)
+		switch entry.Type {
+		case run.AuditRunStarted, run.AuditRunResumed:
+			verb := "Started"
+			if entry.Type == run.AuditRunResumed {
+				verb = "Resumed"
+			}
+			fmt.Fprintf(w, "%s %s by %s on %s (%s, run %s)\n\n", verb, entry.Title, entry.User, entry.Host, entry.Mode, entry.RunID)
+		case run.AuditStep:
+			printAuditStep(w, at, entry)
+		case run.AuditRunEnded:
+			fmt.Fprintf(w, "Ended at %s\n\n", at)
+		}
+	}
+}
+
+func printAuditStep(w io.Writer, at string, entry run.AuditEntry) {
+	step := "outside the runbook"
+	if entry.StepNumber > 0 {
+		step = fmt.Sprintf("step %d, %s", entry.StepNumber, entry.Match)
+	}
+
+	result := "did not finish"
+	if entry.ExitCode != nil {
+		result = fmt.Sprintf("exit code %d", *entry.ExitCode)
+		if entry.FinishedAt != nil {
+			result += fmt.Sprintf(", took %s", entry.FinishedAt.Sub(entry.Time).Round(time.Millisecond))
+		}
+	}
+
+	fmt.Fprintf(w, "[%s] (%s) %s\n", at, step, result)
+	fmt.Fprintf(w, "  $ %s\n", indent(entry.Command, "    "))
+	if entry.Match == run.MatchEdited {
+		fmt.Fprintf(w, "  step: %s\n", indent(entry.Step, "    "))
+	}
+
+	if len(entry.Params) > 0 {
+		keys := make([]string, 0, len(entry.Params))
+		for k := range entry.Params {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		var params []string
+		for _, k := range keys {
+			params = append(params, k+"="+entry.Params[k])
+		}
+		fmt.Fprintf(w, "  params: %s\n", strings.Join(params, " "))
+	}
+
+	if entry.Output != "" {
+		fmt.Fprintf(w, "  output:\n    %s\n", indent(entry.Output, "    "))
+	}
+	fmt.Fprintln(w)
+}
+
+// indent indents every line of s but the first.
+func indent(s, prefix string) string {
+	return strings.ReplaceAll(s, "\n", "\n"+prefix)
+}
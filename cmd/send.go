@@ -3,11 +3,15 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"net"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/getsavvyinc/savvy-cli/display"
+	"github.com/getsavvyinc/savvy-cli/envcontext"
 	"github.com/getsavvyinc/savvy-cli/idgen"
 	"github.com/getsavvyinc/savvy-cli/server"
 	"github.com/getsavvyinc/savvy-cli/shell/expansion"
@@ -50,11 +54,28 @@ var sendCmd = &cobra.Command{
 			quite = true
 		}
 
+		timestamp := parseTimestamp(sendTimestamp)
+
 		data := server.RecordedData{
-			Command:  message,
-			StepID:   sendStepID,
-			ExitCode: exitCode,
-			Prompt:   prompt,
+			Command:      message,
+			StepID:       sendStepID,
+			ExitCode:     exitCode,
+			Prompt:       prompt,
+			WorkingDir:   workingDir,
+			LeadingSpace: leadingSpace,
+			Terminal:     os.Getenv(server.TerminalEnv),
+		}
+
+		// The step id is only provided once the command has finished executing.
+		if quite {
+			data.FinishedAt = timestamp
+		} else {
+			data.StartedAt = timestamp
+			dir := workingDir
+			if dir == "" {
+				dir, _ = os.Getwd()
+			}
+			data.Context = envcontext.Capture(dir)
 		}
 
 		if err := json.NewEncoder(conn).Encode(data); err != nil {
@@ -72,6 +93,22 @@ var sendCmd = &cobra.Command{
 var sendStepID string
 var exitCode int
 var prompt string
+var workingDir string
+var sendTimestamp string
+var leadingSpace bool
+
+// parseTimestamp parses a unix timestamp in (fractional) seconds e.g $EPOCHREALTIME.
+// It falls back to the current time if the timestamp is empty or invalid.
+func parseTimestamp(ts string) time.Time {
+	// EPOCHREALTIME uses the decimal separator of the locale e.g 1700000000,123456.
+	ts = strings.Replace(strings.TrimSpace(ts), ",", ".", 1)
+	secs, err := strconv.ParseFloat(ts, 64)
+	if err != nil || secs <= 0 {
+		return time.Now()
+	}
+	whole, frac := math.Modf(secs)
+	return time.Unix(int64(whole), int64(frac*float64(time.Second)))
+}
 
 func init() {
 	rootCmd.AddCommand(sendCmd)
@@ -80,4 +117,7 @@ func init() {
 	sendCmd.Flags().StringVar(&sendStepID, "step-id", "", "Step ID")
 	sendCmd.Flags().IntVar(&exitCode, "exit-code", 0, "Exit code")
 	sendCmd.Flags().StringVar(&prompt, "prompt", "", "record shell prompt while command is executed")
+	sendCmd.Flags().StringVar(&workingDir, "pwd", "", "working directory the command is executed in")
+	sendCmd.Flags().BoolVar(&leadingSpace, "leading-space", false, "the command was typed with a leading space. Such commands are not recorded by default")
+	sendCmd.Flags().StringVar(&sendTimestamp, "timestamp", "", "unix timestamp in seconds when the command started or finished. Defaults to now")
 }
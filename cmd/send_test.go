@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	testCases := []struct {
+		name     string
+		ts       string
+		expected time.Time
+		now      bool
+	}{
+		{name: "seconds", ts: "1700000000", expected: time.Unix(1700000000, 0)},
+		{name: "fractional seconds", ts: "1700000000.250000", expected: time.Unix(1700000000, 250000000)},
+		{name: "decimal comma", ts: "1700000000,5", expected: time.Unix(1700000000, 500000000)},
+		{name: "surrounding space", ts: " 1700000000\n", expected: time.Unix(1700000000, 0)},
+		{name: "empty", ts: "", now: true},
+		{name: "non-numeric", ts: "$EPOCHREALTIME", now: true},
+		{name: "negative", ts: "-1", now: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			before := time.Now()
+			got := parseTimestamp(tc.ts)
+			if tc.now {
+				assert.False(t, got.Before(before))
+				assert.WithinDuration(t, time.Now(), got, time.Second)
+				return
+			}
+			assert.WithinDuration(t, tc.expected, got, time.Microsecond)
+		})
+	}
+}
@@ -4,7 +4,8 @@
 export __bp_enable_subshells="true"
 
 
-SAVVY_INPUT_FILE=/tmp/savvy-socket
+# savvy record sets SAVVY_SOCKET_PATH to a socket that is unique to each recording session.
+SAVVY_INPUT_FILE=${SAVVY_SOCKET_PATH:-/tmp/savvy-socket}
 
 # Save the original PS1
 orignal_ps1=$PS1
@@ -34,22 +35,46 @@ savvy_cmd_pre_exec() {
   done
   local cmd="${expanded_command}"
   local prompt=$(get_user_prompt)
+  # commands typed with a leading space are ignored by default, like HISTCONTROL=ignorespace
+  local leading_space=false
+  [[ "$1" == " "* ]] && leading_space=true
   step_id=""
   if [[ "${SAVVY_CONTEXT}" == "record" ]] ; then
-    step_id=$(SAVVY_SOCKET_PATH=${SAVVY_INPUT_FILE} savvy send --prompt="${prompt}" "$cmd")
+    step_id=$(SAVVY_SOCKET_PATH=${SAVVY_INPUT_FILE} savvy send --prompt="${prompt}" --pwd="${PWD}" --timestamp="${EPOCHREALTIME}" --leading-space="${leading_space}" "$cmd")
   fi
 }
 
+savvy_recording_indicator=$'\[\e[31m\]recording\[\e[0m\] \U1f60e '
+savvy_paused_indicator=$'\[\e[33m\]paused\[\e[0m\] \U23f8 '
+
 savvy_cmd_pre_cmd() {
   local exit_code=$?
 
-  if [[ "${SAVVY_CONTEXT}" == "record" && "$PS1" != *'recording'* ]]; then
-    PS1+=$'\[\e[31m\]recording\[\e[0m\] \U1f60e '
+  # stop recording in shells attached with savvy record --here or savvy record join once the session ends
+  if [[ -n "${SAVVY_RECORD_HERE}" && ! -S "${SAVVY_INPUT_FILE}" ]]; then
+    PS1="${PS1//"${savvy_recording_indicator}"/}"
+    PS1="${PS1//"${savvy_paused_indicator}"/}"
+    unset SAVVY_CONTEXT SAVVY_RECORD_HERE SAVVY_SOCKET_PATH SAVVY_JOURNAL_PATH SAVVY_TERMINAL
+    SAVVY_INPUT_FILE=/tmp/savvy-socket
+    step_id=""
+  fi
+
+  if [[ "${SAVVY_CONTEXT}" == "record" ]]; then
+    # remove any previous indicator before adding the current one
+    PS1="${PS1//"${savvy_recording_indicator}"/}"
+    PS1="${PS1//"${savvy_paused_indicator}"/}"
+    if [[ -f "${SAVVY_INPUT_FILE}.paused" ]]; then
+      PS1+="${savvy_paused_indicator}"
+    else
+      PS1+="${savvy_recording_indicator}"
+    fi
   fi
 
-  # if return code is not 0, send the return code to the server
-  if [[ "${SAVVY_CONTEXT}" == "record" && "${exit_code}" != "0" ]] ; then
-    SAVVY_SOCKET_PATH=${SAVVY_INPUT_FILE} savvy send --step-id="${step_id}" --exit-code="${exit_code}"
+  # tell the server the step finished so it can record the exit code and stop capturing output
+  if [[ "${SAVVY_CONTEXT}" == "record" && -n "${step_id}" ]] ; then
+    # EPOCHREALTIME is only available in bash 5+. savvy send falls back to the current time when it is empty.
+    SAVVY_SOCKET_PATH=${SAVVY_INPUT_FILE} savvy send --step-id="${step_id}" --exit-code="${exit_code}" --timestamp="${EPOCHREALTIME}"
+    step_id=""
   fi
 }
 
@@ -66,6 +91,28 @@ savvy_runbook_runner() {
   fi
 }
 
+# savvy_run_refresh puts the current step on the command line after the current step changes without running a command.
+savvy_run_refresh() {
+  savvy_run_pre_cmd </dev/tty
+  READLINE_LINE=$(savvy internal current)
+  READLINE_POINT=${#READLINE_LINE}
+}
+
+# skip the current step without running it.
+savvy_run_skip() {
+  if [[ "${SAVVY_CONTEXT}" == "run" ]] ; then
+    SAVVY_NEXT_STEP=$(savvy internal skip)
+    savvy_run_refresh
+  fi
+}
+
+# pick the step to go to from the list of steps.
+savvy_run_pick_step() {
+  if [[ "${SAVVY_CONTEXT}" == "run" ]] ; then
+    SAVVY_NEXT_STEP=$(savvy internal goto </dev/tty)
+    savvy_run_refresh
+  fi
+}
 
 savvy_run_pre_exec() {
   # we want the command as it was typed in.
@@ -82,6 +129,23 @@ PROMPT_RED="\[$(tput setaf 1)\]"
 PROMPT_RESET="\[$(tput sgr0)\]"
 
 savvy_run_pre_cmd() {
+  local exit_code=$?
+
+  if [[ "${SAVVY_CONTEXT}" == "run" ]] ; then
+    # log how the command finished in the audit log of the run, and flag steps that failed their assertion
+    savvy internal finish --exit-code="${exit_code}"
+  fi
+
+  if [[ "${SAVVY_CONTEXT}" == "run" && "${SAVVY_NEXT_STEP}" -lt "${#SAVVY_COMMANDS[@]}" ]] ; then
+    savvy internal set-param
+    # dangerous steps must be confirmed or skipped before they are put on the command line
+    # keep the current step if savvy can't confirm it e.g there is no tty or the run ended.
+    local confirmed_step
+    if confirmed_step=$(savvy internal confirm </dev/tty) && [[ -n "${confirmed_step}" ]] ; then
+      SAVVY_NEXT_STEP=${confirmed_step}
+    fi
+  fi
+
   # transorm 0 based index to 1 based index
   local display_step=$((SAVVY_NEXT_STEP+1))
   local size=${#SAVVY_COMMANDS[@]}
@@ -95,9 +159,6 @@ savvy_run_pre_cmd() {
     PS1="${orignal_ps1}\n(${PROMPT_GREEN}done${PROMPT_RESET}"$' \U1f60e '"${PROMPT_BOLD}${SAVVY_RUN_CURR}${PROMPT_RESET})${PROMPT_GREEN}[exit/ctrl+d to exit]${PROMPT_RESET} "
   fi
 
-  if [[ "${SAVVY_CONTEXT}" == "run" && "${SAVVY_NEXT_STEP}" -lt "${size}" ]] ; then
-    savvy internal set-param
-  fi
 }
 
 add_unique_to_preexec_functions() {
@@ -120,10 +181,14 @@ add_savvy_hooks() {
   if [[ "${SAVVY_CONTEXT}" == "run" ]] ; then
     mapfile -t SAVVY_COMMANDS < <(awk -F'COMMA' '{ for(i=1;i<=NF;i++) print $i }' <<< $SAVVY_RUNBOOK_COMMANDS)
     SAVVY_RUN_CURR="${SAVVY_RUNBOOK_ALIAS}"
+    # resumed runs don't start at the first step.
+    SAVVY_NEXT_STEP=$(savvy internal current --index)
 
     # Set up a keybinding to trigger the function
     bind 'set keyseq-timeout 0'
     bind -x '"\C-n":savvy_runbook_runner'
+    bind -x '"\C-xs":savvy_run_skip'
+    bind -x '"\C-xg":savvy_run_pick_step'
 
     add_unique_to_preexec_functions savvy_run_pre_exec
     add_item_to_precmd_functions savvy_run_pre_cmd
@@ -1,4 +1,9 @@
-set SAVVY_INPUT_FILE /tmp/savvy-socket
+# savvy record sets SAVVY_SOCKET_PATH to a socket that is unique to each recording session.
+if set -q SAVVY_SOCKET_PATH
+    set SAVVY_INPUT_FILE $SAVVY_SOCKET_PATH
+else
+    set SAVVY_INPUT_FILE /tmp/savvy-socket
+end
 
 
 # Fish automatically loads completions, so no need for 'autoload' or 'compinit'
@@ -25,6 +30,11 @@ function __savvy_record_prompt --description "Modify prompt for Savvy recording"
         set -l original_prompt (__pre_savvy_record_prompt)
 
         if test "$SAVVY_CONTEXT" = "record"
+          and test -f "$SAVVY_INPUT_FILE.paused"
+          echo -n $original_prompt
+          echo -n (set_color yellow)"paused"(set_color normal)" ⏸>"
+          echo -n "  "
+        else if test "$SAVVY_CONTEXT" = "record"
           and not string match -q '*recording*' "$fish_prompt"
           echo -n $original_prompt
           echo -n (set_color green)"recording"(set_color normal)" 😎>"
@@ -57,6 +67,15 @@ function __savvy_run_pre_exec__ --on-event fish_preexec
     end
 end
 
+function __savvy_run_post_exec__ --on-event fish_postexec
+    set -l exit_code $status
+
+    if test "$SAVVY_CONTEXT" = "run"
+        # log how the command finished in the audit log of the run, and flag steps that failed their assertion
+        savvy internal finish --exit-code="$exit_code"
+    end
+end
+
 function __savvy_run_prompt --description "Modify prompt for Savvy run"
     # Save the original prompt function if not already saved
     if not functions -q __pre_savvy_run_prompt
@@ -111,19 +130,34 @@ end
 
 __savvy_run_prompt
 
+if test "$SAVVY_CONTEXT" = "run"
+    # resumed runs don't start at the first step.
+    set -g SAVVY_NEXT_STEP (savvy internal current --index)
+end
+
 
 function __savvy_record_post_exec --on-event fish_postexec
     set -l exit_code $status
 
+    # stop recording in shells attached with savvy record --here or savvy record join once the session ends
+    if set -q SAVVY_RECORD_HERE
+      and not test -S "$SAVVY_INPUT_FILE"
+        set -e SAVVY_CONTEXT SAVVY_RECORD_HERE SAVVY_SOCKET_PATH SAVVY_JOURNAL_PATH SAVVY_TERMINAL
+        set -g SAVVY_INPUT_FILE /tmp/savvy-socket
+        set -g step_id ""
+    end
+
     if not test "$SAVVY_CONTEXT" = "record"
         return
     end
 
-    # Send the return code to the server if it's not 0
+    # Tell the server the step finished so it can record the exit code and stop capturing output
     if test "$SAVVY_CONTEXT" = "record"
-      and test "$exit_code" -ne 0
+      and test -n "$step_id"
         set -x SAVVY_SOCKET_PATH $SAVVY_INPUT_FILE
-        savvy send --step-id="$step_id" --exit-code="$status"
+        # fish has no EPOCHREALTIME, so the timestamp is in whole seconds.
+        savvy send --step-id="$step_id" --exit-code="$exit_code" --timestamp=(date +%s)
+        set -g step_id ""
     end
 end
 
@@ -139,10 +173,17 @@ function __savvy_record_pre_exec__ --on-event fish_preexec
     # Clear step_id
     set -g step_id ""
 
+    # commands typed with a leading space are ignored by default, like HIST_IGNORE_SPACE
+    set -l leading_space false
+    if string match -q -- ' *' $cmd
+        set leading_space true
+    end
+
     if test "$SAVVY_CONTEXT" = "record"
+        set -l prompt (string join \n -- (fish_prompt))
         set -g step_id (
             env SAVVY_SOCKET_PATH=$SAVVY_INPUT_FILE \
-            savvy send $cmd
+            savvy send --prompt="$prompt" --pwd="$PWD" --timestamp=(date +%s) --leading-space="$leading_space" $cmd
         )
     end
 end
@@ -173,6 +214,11 @@ function __savvy_run_completion__
         return
     end
 
+    # dangerous steps must be confirmed or skipped before they are put on the command line
+    # keep the current step if savvy can't confirm it e.g there is no tty or the run ended.
+    if set -l confirmed_step (savvy internal confirm </dev/tty); and test -n "$confirmed_step"
+        set -g SAVVY_NEXT_STEP $confirmed_step
+    end
     set -l run_cmd (savvy internal current)
     set -l cmd (commandline -o)
 
@@ -183,11 +229,36 @@ function __savvy_run_completion__
     end
 end
 
+# skip the current step without running it.
+function __savvy_run_skip__
+    if not test "$SAVVY_CONTEXT" = "run"
+        return
+    end
+
+    set -g SAVVY_NEXT_STEP (savvy internal skip)
+    commandline --replace (savvy internal current)
+    commandline -f repaint
+end
+
+# pick the step to go to from the list of steps.
+function __savvy_run_pick_step__
+    if not test "$SAVVY_CONTEXT" = "run"
+        return
+    end
+
+    set -g SAVVY_NEXT_STEP (savvy internal goto </dev/tty)
+    commandline --replace (savvy internal current)
+    commandline -f repaint
+end
+
 function trigger_run_autocomplete --on-event fish_complete_command
     if not test "$SAVVY_CONTEXT" = "run"
         return
     end
     bind \cn '__savvy_run_completion__'
+    # ctrl-x s skips the current step, ctrl-x g picks the step to go to.
+    bind \cxs '__savvy_run_skip__'
+    bind \cxg '__savvy_run_pick_step__'
 end
 
 trigger_run_autocomplete
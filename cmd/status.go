@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/getsavvyinc/savvy-cli/display"
+	"github.com/getsavvyinc/savvy-cli/server"
+	"github.com/getsavvyinc/savvy-cli/theme"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var statusList bool
+var statusWatch bool
+
+// statusCmd shows the steps captured by the recording session and lets the user choose which ones to export.
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the recorded steps and choose which ones to export",
+	Long: `Show the steps recorded so far, with their exit codes, and choose which ones are exported when the recording ends.
+
+Run it in the recording shell, or in another terminal e.g a split pane next to the recording shell.`,
+	Example: `
+  # Toggle which steps are exported
+  savvy record status
+
+  # Keep a live list of the recorded steps in a split pane
+  savvy record status --watch
+  `,
+	Run: func(cmd *cobra.Command, args []string) {
+		socketPath := os.Getenv(server.SocketPathEnv)
+		if socketPath == "" {
+			var err error
+			if socketPath, err = sessionToJoin(nil); err != nil {
+				display.Error(err)
+				os.Exit(1)
+			}
+		}
+
+		cl, err := server.NewClient(cmd.Context(), socketPath)
+		if err != nil {
+			display.Error(err)
+			os.Exit(1)
+		}
+
+		if statusWatch {
+			watchStatus(cmd.Context(), cl)
+			return
+		}
+
+		status, err := cl.Status()
+		if err != nil {
+			display.ErrorWithSupportCTA(err)
+			os.Exit(1)
+		}
+
+		if len(status.Steps) == 0 {
+			display.Info("No steps recorded yet")
+			return
+		}
+
+		if statusList || !term.IsTerminal(int(os.Stdout.Fd())) {
+			fmt.Print(formatStatus(status))
+			return
+		}
+
+		if err := selectExportedSteps(cl, status); err != nil {
+			display.Error(err)
+			os.Exit(1)
+		}
+	},
+}
+
+// selectExportedSteps lets the user toggle which steps are exported.
+func selectExportedSteps(cl server.Client, status *server.Status) error {
+	var options []huh.Option[string]
+	for i, step := range status.Steps {
+		label := fmt.Sprintf("%3d %s %s", i+1, stepResult(step), stepText(step))
+		options = append(options, huh.NewOption(label, step.StepID).Selected(!step.Excluded))
+	}
+
+	var included []string
+	description := "Toggle steps with x or space. Press enter to save."
+	if status.IgnoreErrors {
+		description += " Failed steps are not exported because of --ignore-errors."
+	}
+	t := theme.New()
+	field := huh.NewMultiSelect[string]().
+		Title("Steps to Export").
+		Description(description).
+		Options(options...).
+		Value(&included)
+	if err := huh.NewForm(huh.NewGroup(field)).WithTheme(t).Run(); err != nil {
+		return err
+	}
+
+	isIncluded := make(map[string]bool, len(included))
+	for _, id := range included {
+		isIncluded[id] = true
+	}
+	var excluded []string
+	for _, step := range status.Steps {
+		if !isIncluded[step.StepID] {
+			excluded = append(excluded, step.StepID)
+		}
+	}
+
+	if err := cl.SendExcludedSteps(excluded); err != nil {
+		return fmt.Errorf("failed to update the recording: %w", err)
+	}
+	display.Info(fmt.Sprintf("%d of %d steps will be exported", len(status.Steps)-len(excluded), len(status.Steps)))
+	return nil
+}
+
+// watchStatusInterval is how often the live list of steps is refreshed.
+const watchStatusInterval = time.Second
+
+// watchStatus keeps redrawing the recorded steps until the recording session ends or the user presses ctrl-c.
+func watchStatus(ctx context.Context, cl server.Client) {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	ticker := time.NewTicker(watchStatusInterval)
+	defer ticker.Stop()
+
+	for {
+		status, err := cl.Status()
+		if err != nil {
+			display.Info("The recording session ended")
+			return
+		}
+
+		// clear the screen and move the cursor to the top left corner.
+		fmt.Print("\033[H\033[2J")
+		if status.Paused {
+			fmt.Println("Recording is paused")
+		}
+		if len(status.Steps) == 0 {
+			fmt.Println("No steps recorded yet")
+		}
+		fmt.Print(formatStatus(status))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func formatStatus(status *server.Status) string {
+	var b strings.Builder
+	for i, step := range status.Steps {
+		line := fmt.Sprintf("%3d %s %s", i+1, stepResult(step), stepText(step))
+		if !status.Included(step) {
+			line += " (not exported)"
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// stepResult returns a short description of how the step finished e.g ✓ or ✗ 127.
+func stepResult(step server.StepStatus) string {
+	switch {
+	case !step.Finished:
+		return "…    "
+	case step.ExitCode == 0:
+		return "✓    "
+	default:
+		return fmt.Sprintf("✗ %-3d", step.ExitCode)
+	}
+}
+
+func stepText(step server.StepStatus) string {
+	text := step.Command
+	if step.Note != "" {
+		text = "# " + step.Note
+	}
+	// keep multiline commands on one line.
+	text = strings.Join(strings.Fields(text), " ")
+	if step.Terminal != "" {
+		text = fmt.Sprintf("[%s] %s", step.Terminal, text)
+	}
+	return text
+}
+
+func init() {
+	statusCmd.Flags().BoolVar(&statusList, "list", false, "Print the recorded steps without prompting")
+	statusCmd.Flags().BoolVar(&statusWatch, "watch", false, "Keep showing the recorded steps as they are captured")
+	statusCmd.MarkFlagsMutuallyExclusive("list", "watch")
+	recordCmd.AddCommand(statusCmd)
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/getsavvyinc/savvy-cli/display"
+	"github.com/getsavvyinc/savvy-cli/server"
+	"github.com/spf13/cobra"
+)
+
+// undoCmd represents the undo command
+var undoCmd = &cobra.Command{
+	Use:   "undo [n]",
+	Short: "Remove the last n recorded steps",
+	Example: `
+  # Remove the last recorded step
+  savvy record undo
+
+  # Remove the last 3 recorded steps
+  savvy record undo 3
+  `,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		n := 1
+		if len(args) == 1 {
+			var err error
+			n, err = strconv.Atoi(args[0])
+			if err != nil || n < 1 {
+				display.ErrorMsg("n must be a positive number")
+				return
+			}
+		}
+
+		cl, err := server.NewDefaultClient(context.Background())
+		if err != nil {
+			display.Error(err)
+			return
+		}
+
+		removed, err := cl.SendUndo(n)
+		if err != nil {
+			display.ErrorWithSupportCTA(err)
+			return
+		}
+		if removed == 0 {
+			display.Info("There are no recorded steps to remove")
+			return
+		}
+		display.Info(fmt.Sprintf("Removed the last %d recorded step(s)", removed))
+	},
+}
+
+func init() {
+	recordCmd.AddCommand(undoCmd)
+}
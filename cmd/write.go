@@ -3,12 +3,15 @@ package cmd
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 
 	"github.com/charmbracelet/huh"
 	huhSpinner "github.com/charmbracelet/huh/spinner"
+	"github.com/getsavvyinc/savvy-cli/archive"
 	"github.com/getsavvyinc/savvy-cli/client"
+	"github.com/getsavvyinc/savvy-cli/diff"
 	"github.com/getsavvyinc/savvy-cli/display"
 	"github.com/spf13/cobra"
 )
@@ -24,6 +27,7 @@ var writeCmd = &cobra.Command{
   Write writes a file to your file system.
 
   Write is used while running a runbook to write important data to your filesystem.
+  Recorded directories are extracted and recorded changes to a file are applied to the existing file.
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		cl, err := client.New()
@@ -47,6 +51,15 @@ var writeCmd = &cobra.Command{
 			return
 		}
 
+		switch stepContent.Type {
+		case client.StepTypeDirectory:
+			writeDirectory(stepContent)
+			return
+		case client.StepTypePatch:
+			writePatch(stepContent)
+			return
+		}
+
 		// Write to the file system at the specified place.
 		// Allow the user to overwrite the name/path of the file
 		filename := stepContent.Name
@@ -127,3 +140,92 @@ func writeFile(path string, buf *bytes.Buffer) {
 
 	display.Info("File written to " + path)
 }
+
+// writeDirectory extracts a directory recorded as a tarball.
+func writeDirectory(stepContent *client.StepContent) {
+	dirPath := stepContent.DirPath
+	if len(dirPath) == 0 {
+		dirPath = "."
+	}
+
+	for {
+		maybeEditDirPath := huh.NewInput().
+			Title("Confirm or Edit Directory Path").
+			Description(fmt.Sprintf("%s will be created in this directory", stepContent.Name)).
+			Prompt("> ").
+			Value(&dirPath)
+
+		if err := huh.NewForm(huh.NewGroup(maybeEditDirPath)).Run(); err != nil {
+			display.FatalErrWithSupportCTA(err)
+			return
+		}
+
+		target := filepath.Join(dirPath, stepContent.Name)
+		if _, err := os.Stat(target); err != nil {
+			break
+		}
+
+		var confirmation bool
+		confirmOverwrite := huh.NewConfirm().Title("Directory already exists").Description("Overwrite files in " + target + "?").Value(&confirmation)
+		if err := huh.NewForm(huh.NewGroup(confirmOverwrite)).WithTheme(huh.ThemeDracula()).Run(); err != nil {
+			display.FatalErrWithSupportCTA(err)
+			return
+		}
+		if confirmation {
+			break
+		}
+	}
+
+	if err := archive.Extract(stepContent.Content, dirPath); err != nil {
+		display.Error(fmt.Errorf("failed to write directory: %w", err))
+		return
+	}
+	display.Info("Directory written to " + filepath.Join(dirPath, stepContent.Name))
+}
+
+// writePatch applies a recorded diff to a file that already exists.
+func writePatch(stepContent *client.StepContent) {
+	path := filepath.Join(stepContent.DirPath, stepContent.Name)
+
+	for {
+		maybeEditPath := huh.NewInput().
+			Title("Confirm or Edit the File to Change").
+			Description("Press enter to apply the recorded changes to this file").
+			Prompt("> ").
+			Value(&path)
+
+		if err := huh.NewForm(huh.NewGroup(maybeEditPath)).Run(); err != nil {
+			display.FatalErrWithSupportCTA(err)
+			return
+		}
+
+		if fileExists(path) {
+			break
+		}
+		display.ErrorMsg(fmt.Sprintf("%s doesn't exist: the recorded changes can only be applied to an existing file", path))
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		display.Error(err)
+		return
+	}
+
+	original, err := os.ReadFile(path)
+	if err != nil {
+		display.Error(err)
+		return
+	}
+
+	patched, err := diff.Apply(string(original), string(stepContent.Content))
+	if err != nil {
+		display.Error(fmt.Errorf("failed to apply changes to %s: %w", path, err))
+		return
+	}
+
+	if err := os.WriteFile(path, []byte(patched), fi.Mode().Perm()); err != nil {
+		display.Error(err)
+		return
+	}
+	display.Info("Changes applied to " + path)
+}
@@ -0,0 +1,28 @@
+package danger
+
+// builtinRules flag commands that destroy data or infrastructure and are hard or impossible to undo.
+var builtinRules = []Rule{
+	{Pattern: `\brm\s+(\S+\s+)*-[a-zA-Z]*([rR][a-zA-Z]*f|f[a-zA-Z]*[rR])`, Reason: "rm -rf deletes files recursively without asking"},
+	{Pattern: `\brm\s+(\S+\s+)*(-[rR]|--recursive)\s+(\S+\s+)*(-f|--force)\b`, Reason: "rm -rf deletes files recursively without asking"},
+	{Pattern: `\brm\s+(\S+\s+)*(-f|--force)\s+(\S+\s+)*(-[rR]|--recursive)\b`, Reason: "rm -rf deletes files recursively without asking"},
+	{Pattern: `\bkubectl\s+(\S+\s+)*(delete|drain)\b`, Reason: "kubectl delete and drain remove workloads from the cluster"},
+	{Pattern: `\bhelm\s+(\S+\s+)*(uninstall|delete)\b`, Reason: "helm uninstall removes a release from the cluster"},
+	{Pattern: `(?i)\bdrop\s+(table|database|schema)\b`, Reason: "DROP deletes a table, database or schema and its data"},
+	{Pattern: `(?i)\btruncate\s+table\b`, Reason: "TRUNCATE deletes every row of a table"},
+	{Pattern: "(?i)\\bdelete\\s+from\\s+[\\w.\"`]+\\s*(;|\"|'|$)", Reason: "DELETE without a WHERE clause deletes every row of a table"},
+	{Pattern: `(?i)\bflush(all|db)\b`, Reason: "FLUSHALL and FLUSHDB delete every key"},
+	{Pattern: `\bterraform\s+(\S+\s+)*(destroy|apply\s+(\S+\s+)*-destroy)\b`, Reason: "terraform destroy deletes the infrastructure it manages"},
+	{Pattern: `\bgit\s+push\s+(\S+\s+)*(--force|-f)\b`, Reason: "force pushing overwrites the history of the remote branch"},
+	{Pattern: `\bgit\s+reset\s+(\S+\s+)*--hard\b`, Reason: "git reset --hard discards uncommitted changes"},
+	{Pattern: `\bgit\s+clean\s+(\S+\s+)*-[a-zA-Z]*f`, Reason: "git clean -f deletes untracked files"},
+	{Pattern: `\bmkfs(\.\w+)?\s`, Reason: "mkfs formats a device and erases its data"},
+	{Pattern: `\bdd\s+(\S+\s+)*of=/dev/`, Reason: "dd overwrites a device"},
+	{Pattern: `>\s*/dev/(sd|hd|nvme|disk|xvd)`, Reason: "writing to a disk device overwrites its data"},
+	{Pattern: `:\(\)\s*\{\s*:\s*\|\s*:\s*&\s*\}\s*;\s*:`, Reason: "a fork bomb makes the machine unresponsive"},
+	{Pattern: `(^|[;&|]\s*|\bsudo\s+)(shutdown|reboot|halt|poweroff)\b`, Reason: "shuts down or reboots the machine"},
+	{Pattern: `\bdocker\s+(system|volume|image|container|network)\s+prune\b`, Reason: "docker prune deletes containers, images or volumes"},
+	{Pattern: `\baws\s+s3\s+(rb|rm\s+(\S+\s+)*--recursive)\b`, Reason: "deletes S3 buckets or objects"},
+	{Pattern: `\baws\s+(\S+\s+)*(delete|terminate)-[a-z-]+`, Reason: "deletes AWS resources"},
+	{Pattern: `\bgcloud\s+(\S+\s+)*delete\b`, Reason: "deletes Google Cloud resources"},
+	{Pattern: `\baz\s+(\S+\s+)*delete\b`, Reason: "deletes Azure resources"},
+}
@@ -0,0 +1,130 @@
+// Package danger flags commands that destroy data or infrastructure, so that they can be confirmed before they run.
+package danger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/getsavvyinc/savvy-cli/config"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultRulesFilePath holds the user's rules for dangerous commands.
+var DefaultRulesFilePath = filepath.Join(config.DefaultConfigDir, "danger.yaml")
+
+// Rules configure which commands are dangerous.
+//
+// Example:
+//
+//	rules:
+//	  - pattern: '^helm upgrade .*--namespace prod'
+//	    reason: upgrades a release in production
+//	allow:
+//	  - '^kubectl delete pod '
+type Rules struct {
+	// Rules flag the commands that match them, in addition to the built-in rules.
+	Rules []Rule `yaml:"rules"`
+	// Allow are regular expressions. Commands that match any of them are never flagged.
+	Allow []string `yaml:"allow"`
+	// DisableBuiltinRules turns off the built-in rules, so that only Rules apply.
+	DisableBuiltinRules bool `yaml:"disable_builtin_rules"`
+}
+
+// Rule flags commands that match Pattern, a regular expression, as dangerous. Reason explains why.
+type Rule struct {
+	Pattern string `yaml:"pattern"`
+	Reason  string `yaml:"reason"`
+}
+
+// Classifier flags dangerous commands.
+type Classifier struct {
+	rules []compiledRule
+	allow []*regexp.Regexp
+}
+
+type compiledRule struct {
+	re     *regexp.Regexp
+	reason string
+}
+
+// defaultReason is used for user rules without a reason.
+const defaultReason = "matches a rule for dangerous commands"
+
+// NewClassifier returns a classifier that applies the built-in rules and rules.
+func NewClassifier(rules *Rules) (*Classifier, error) {
+	if rules == nil {
+		rules = &Rules{}
+	}
+
+	all := rules.Rules
+	if !rules.DisableBuiltinRules {
+		all = append(builtinRules[:len(builtinRules):len(builtinRules)], rules.Rules...)
+	}
+
+	c := &Classifier{}
+	for _, rule := range all {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", rule.Pattern, err)
+		}
+		reason := rule.Reason
+		if reason == "" {
+			reason = defaultReason
+		}
+		c.rules = append(c.rules, compiledRule{re: re, reason: reason})
+	}
+
+	for _, pattern := range rules.Allow {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allow pattern %q: %w", pattern, err)
+		}
+		c.allow = append(c.allow, re)
+	}
+	return c, nil
+}
+
+// LoadClassifier returns a classifier that applies the built-in rules and the user's rules in DefaultRulesFilePath.
+// It is not an error if the file doesn't exist.
+func LoadClassifier() (*Classifier, error) {
+	rules := &Rules{}
+	bs, err := os.ReadFile(DefaultRulesFilePath)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		if err := yaml.Unmarshal(bs, rules); err != nil {
+			return nil, fmt.Errorf("invalid rules for dangerous commands in %s: %w", DefaultRulesFilePath, err)
+		}
+	}
+
+	c, err := NewClassifier(rules)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rules for dangerous commands in %s: %w", DefaultRulesFilePath, err)
+	}
+	return c, nil
+}
+
+// Classify reports whether command is dangerous and why.
+func (c *Classifier) Classify(command string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+
+	for _, re := range c.allow {
+		if re.MatchString(command) {
+			return "", false
+		}
+	}
+
+	for _, rule := range c.rules {
+		if rule.re.MatchString(command) {
+			return rule.reason, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,84 @@
+package danger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassify(t *testing.T) {
+	c, err := NewClassifier(nil)
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		command   string
+		dangerous bool
+	}{
+		{command: "rm -rf build/", dangerous: true},
+		{command: "sudo rm -fr /var/lib/app", dangerous: true},
+		{command: "rm -r -f build/", dangerous: true},
+		{command: "rm --force --recursive build/", dangerous: true},
+		{command: "rm build/app.log", dangerous: false},
+		{command: "rm -r build/", dangerous: false},
+		{command: "kubectl -n prod delete deployment api", dangerous: true},
+		{command: "kubectl get pods", dangerous: false},
+		{command: `psql -c "DROP TABLE users"`, dangerous: true},
+		{command: `psql -c "delete from users;"`, dangerous: true},
+		{command: `psql -c "delete from users where id = 1;"`, dangerous: false},
+		{command: "terraform destroy -auto-approve", dangerous: true},
+		{command: "terraform apply -destroy", dangerous: true},
+		{command: "terraform plan", dangerous: false},
+		{command: "git push --force origin main", dangerous: true},
+		{command: "git push origin main", dangerous: false},
+		{command: "git reset --hard HEAD~1", dangerous: true},
+		{command: "dd if=image.iso of=/dev/sdb bs=4M", dangerous: true},
+		{command: "sudo reboot", dangerous: true},
+		{command: "echo reboot", dangerous: false},
+		{command: "aws ec2 terminate-instances --instance-ids i-123", dangerous: true},
+		{command: "aws s3 ls", dangerous: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.command, func(t *testing.T) {
+			reason, dangerous := c.Classify(tc.command)
+			assert.Equal(t, tc.dangerous, dangerous)
+			if dangerous {
+				assert.NotEmpty(t, reason)
+			}
+		})
+	}
+}
+
+func TestUserRules(t *testing.T) {
+	c, err := NewClassifier(&Rules{
+		Rules: []Rule{
+			{Pattern: `--namespace prod\b`, Reason: "changes production"},
+			{Pattern: `^make deploy`},
+		},
+		Allow: []string{`^kubectl delete pod `},
+	})
+	assert.NoError(t, err)
+
+	reason, dangerous := c.Classify("helm upgrade api ./chart --namespace prod")
+	assert.True(t, dangerous)
+	assert.Equal(t, "changes production", reason)
+
+	reason, dangerous = c.Classify("make deploy")
+	assert.True(t, dangerous)
+	assert.Equal(t, defaultReason, reason)
+
+	_, dangerous = c.Classify("kubectl delete pod api-123")
+	assert.False(t, dangerous, "allowed commands are never flagged")
+
+	t.Run("disable builtin rules", func(t *testing.T) {
+		c, err := NewClassifier(&Rules{DisableBuiltinRules: true})
+		assert.NoError(t, err)
+		_, dangerous := c.Classify("rm -rf /")
+		assert.False(t, dangerous)
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		_, err := NewClassifier(&Rules{Rules: []Rule{{Pattern: "("}}})
+		assert.Error(t, err)
+	})
+}
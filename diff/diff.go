@@ -0,0 +1,280 @@
+// Package diff creates and applies unified diffs of text files.
+package diff
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines around each change in a hunk.
+const contextLines = 3
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+type op struct {
+	kind opKind
+	line string
+}
+
+// Unified returns a unified diff that turns oldText into newText.
+// It returns an empty string if the texts are equal.
+func Unified(oldName, newName, oldText, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+
+	ops := diffLines(splitLines(oldText), splitLines(newText))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+	for _, h := range hunks(ops) {
+		b.WriteString(h)
+	}
+	return b.String()
+}
+
+// splitLines splits text into lines, keeping the line endings so that a missing newline at the end of the file is preserved.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns the shortest edit script from a to b using Myers' algorithm.
+func diffLines(a, b []string) []op {
+	n, m := len(a), len(b)
+	maxD := n + m
+	offset := maxD
+	v := make([]int, 2*maxD+2)
+	var trace [][]int
+
+	found := false
+	for d := 0; d <= maxD && !found; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+	// the state after the last step is needed to backtrack from it.
+	trace = append(trace, v)
+
+	var ops []op
+	x, y := n, m
+	for d := len(trace) - 2; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, op{kind: opEqual, line: a[x]})
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			y--
+			ops = append(ops, op{kind: opInsert, line: b[y]})
+		} else {
+			x--
+			ops = append(ops, op{kind: opDelete, line: a[x]})
+		}
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// hunks groups the edit script into unified diff hunks with contextLines of context.
+func hunks(ops []op) []string {
+	var result []string
+
+	oldLine, newLine := 1, 1
+	for i := 0; i < len(ops); {
+		if ops[i].kind == opEqual {
+			oldLine++
+			newLine++
+			i++
+			continue
+		}
+
+		// include up to contextLines of leading context.
+		start := i
+		for start > 0 && i-start < contextLines && ops[start-1].kind == opEqual {
+			start--
+		}
+		oldStart, newStart := oldLine-(i-start), newLine-(i-start)
+
+		// extend the hunk until there are more than 2*contextLines unchanged lines in a row.
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != opEqual {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == opEqual {
+				run++
+			}
+			if run == len(ops) || run-end > 2*contextLines {
+				end += min(run-end, contextLines)
+				break
+			}
+			end = run
+		}
+
+		var body strings.Builder
+		oldCount, newCount := 0, 0
+		for _, o := range ops[start:end] {
+			switch o.kind {
+			case opEqual:
+				body.WriteString(" ")
+				oldCount++
+				newCount++
+			case opDelete:
+				body.WriteString("-")
+				oldCount++
+			case opInsert:
+				body.WriteString("+")
+				newCount++
+			}
+			body.WriteString(o.line)
+			if !strings.HasSuffix(o.line, "\n") {
+				body.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+
+		result = append(result, fmt.Sprintf("@@ -%s +%s @@\n%s", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount), body.String()))
+
+		for _, o := range ops[i:end] {
+			if o.kind != opInsert {
+				oldLine++
+			}
+			if o.kind != opDelete {
+				newLine++
+			}
+		}
+		i = end
+	}
+	return result
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		// an empty range starts at the line before the change.
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return strconv.Itoa(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+var hunkHeaderRegex = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// ErrPatchDoesNotApply is returned when the original text doesn't match the context of the patch.
+var ErrPatchDoesNotApply = errors.New("patch does not apply")
+
+// Apply applies a unified diff created by Unified to original.
+func Apply(original, patch string) (string, error) {
+	lines := splitLines(original)
+	patchLines := splitLines(patch)
+
+	var result []string
+	next := 0 // index of the next line of original to copy
+
+	for i := 0; i < len(patchLines); i++ {
+		m := hunkHeaderRegex.FindStringSubmatch(patchLines[i])
+		if m == nil {
+			// skip the file header and anything else outside of hunks.
+			continue
+		}
+
+		oldStart, _ := strconv.Atoi(m[1])
+		oldCount := 1
+		if m[2] != "" {
+			oldCount, _ = strconv.Atoi(m[2])
+		}
+		// empty ranges start at the line before the change.
+		start := oldStart - 1
+		if oldCount == 0 {
+			start = oldStart
+		}
+		if start < next || start > len(lines) {
+			return "", fmt.Errorf("%w: hunk %q is out of range", ErrPatchDoesNotApply, strings.TrimSpace(patchLines[i]))
+		}
+		result = append(result, lines[next:start]...)
+		next = start
+
+		for i+1 < len(patchLines) && !hunkHeaderRegex.MatchString(patchLines[i+1]) {
+			i++
+			line := patchLines[i]
+			if line == "" {
+				continue
+			}
+			text := line[1:]
+			// the marker applies to the previous line.
+			if i+1 < len(patchLines) && strings.HasPrefix(patchLines[i+1], `\ `) {
+				text = strings.TrimSuffix(text, "\n")
+				i++
+			}
+
+			switch line[0] {
+			case ' ', '-':
+				if next >= len(lines) || lines[next] != text {
+					return "", fmt.Errorf("%w: line %d doesn't match", ErrPatchDoesNotApply, next+1)
+				}
+				if line[0] == ' ' {
+					result = append(result, text)
+				}
+				next++
+			case '+':
+				result = append(result, text)
+			default:
+				return "", fmt.Errorf("%w: invalid line %q", ErrPatchDoesNotApply, strings.TrimSpace(line))
+			}
+		}
+	}
+
+	result = append(result, lines[next:]...)
+	return strings.Join(result, ""), nil
+}
@@ -0,0 +1,75 @@
+package diff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/getsavvyinc/savvy-cli/diff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnified(t *testing.T) {
+	oldText := "replicas: 1\nimage: api:v1\nport: 8080\n"
+	newText := "replicas: 3\nimage: api:v1\nport: 8080\n"
+
+	expected := `--- a/deploy.yaml
++++ b/deploy.yaml
+@@ -1,3 +1,3 @@
+-replicas: 1
++replicas: 3
+ image: api:v1
+ port: 8080
+`
+	assert.Equal(t, expected, diff.Unified("a/deploy.yaml", "b/deploy.yaml", oldText, newText))
+	assert.Empty(t, diff.Unified("a", "b", oldText, oldText))
+}
+
+func TestApply(t *testing.T) {
+	var lines []string
+	for i := 0; i < 40; i++ {
+		lines = append(lines, "line "+strings.Repeat("x", i%7))
+	}
+	original := strings.Join(lines, "\n") + "\n"
+
+	testCases := []struct {
+		name     string
+		original string
+		modified string
+	}{
+		{
+			name:     "separate hunks",
+			original: original,
+			modified: strings.Replace(strings.Replace(original, "line \n", "first\n", 1), "line xxxxxx\n", "changed\nadded\n", 1),
+		},
+		{
+			name:     "append to empty file",
+			original: "",
+			modified: "hello\n",
+		},
+		{
+			name:     "delete everything",
+			original: "a\nb\n",
+			modified: "",
+		},
+		{
+			name:     "no newline at end of file",
+			original: "a\nb",
+			modified: "a\nc",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			patch := diff.Unified("a", "b", tc.original, tc.modified)
+			applied, err := diff.Apply(tc.original, patch)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.modified, applied)
+		})
+	}
+
+	t.Run("conflict", func(t *testing.T) {
+		patch := diff.Unified("a", "b", "a\nb\n", "a\nc\n")
+		_, err := diff.Apply("x\ny\n", patch)
+		assert.ErrorIs(t, err, diff.ErrPatchDoesNotApply)
+	})
+}
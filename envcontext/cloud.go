@@ -0,0 +1,111 @@
+package envcontext
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type kubeConfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Contexts       []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Namespace string `yaml:"namespace"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+}
+
+// kubeContext returns the current kubectl context and its namespace.
+// Like kubectl, it merges the files in $KUBECONFIG and the first file to set a value wins.
+func kubeContext(home string, getenv func(string) string) (context, namespace string) {
+	paths := filepath.SplitList(getenv("KUBECONFIG"))
+	if len(paths) == 0 && home != "" {
+		paths = []string{filepath.Join(home, ".kube", "config")}
+	}
+
+	var configs []kubeConfig
+	for _, path := range paths {
+		bs, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var cfg kubeConfig
+		if err := yaml.Unmarshal(bs, &cfg); err != nil {
+			continue
+		}
+		configs = append(configs, cfg)
+		if context == "" {
+			context = cfg.CurrentContext
+		}
+	}
+
+	if context == "" {
+		return "", ""
+	}
+
+	for _, cfg := range configs {
+		for _, c := range cfg.Contexts {
+			if c.Name == context {
+				return context, c.Context.Namespace
+			}
+		}
+	}
+	return context, ""
+}
+
+// gcloudProject returns the project of the active gcloud configuration.
+func gcloudProject(home string, getenv func(string) string) string {
+	if project := getenv("CLOUDSDK_CORE_PROJECT"); project != "" {
+		return project
+	}
+
+	configDir := getenv("CLOUDSDK_CONFIG")
+	if configDir == "" {
+		if home == "" {
+			return ""
+		}
+		configDir = filepath.Join(home, ".config", "gcloud")
+	}
+
+	name := getenv("CLOUDSDK_ACTIVE_CONFIG_NAME")
+	if name == "" {
+		bs, err := os.ReadFile(filepath.Join(configDir, "active_config"))
+		if err != nil {
+			return ""
+		}
+		name = strings.TrimSpace(string(bs))
+	}
+
+	return iniValue(filepath.Join(configDir, "configurations", "config_"+name), "core", "project")
+}
+
+// iniValue returns the value of key in section of the ini file at path.
+func iniValue(path, section, key string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	var inSection bool
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inSection = line == "["+section+"]"
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if ok && strings.TrimSpace(k) == key {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,166 @@
+// Package envcontext captures the environment a command runs against e.g the git branch, kube context or cloud account.
+package envcontext
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Snapshot is the environment a recorded command ran against.
+// Fields that couldn't be determined are empty.
+type Snapshot struct {
+	GitRepo       string `json:"git_repo,omitempty"`
+	GitBranch     string `json:"git_branch,omitempty"`
+	GitCommit     string `json:"git_commit,omitempty"`
+	KubeContext   string `json:"kube_context,omitempty"`
+	KubeNamespace string `json:"kube_namespace,omitempty"`
+	AWSProfile    string `json:"aws_profile,omitempty"`
+	GCloudProject string `json:"gcloud_project,omitempty"`
+	Virtualenv    string `json:"virtualenv,omitempty"`
+}
+
+// Capture returns the environment of a command that runs in dir.
+// It only reads files and environment variables so that it is cheap enough to run before every command.
+// It returns nil if nothing could be determined.
+func Capture(dir string) *Snapshot {
+	home, _ := os.UserHomeDir()
+	return capture(dir, home, os.Getenv)
+}
+
+func capture(dir, home string, getenv func(string) string) *Snapshot {
+	s := &Snapshot{
+		AWSProfile:    firstNonEmpty(getenv("AWS_PROFILE"), getenv("AWS_DEFAULT_PROFILE")),
+		GCloudProject: gcloudProject(home, getenv),
+		Virtualenv:    virtualenv(getenv),
+	}
+	s.GitRepo, s.GitBranch, s.GitCommit = gitInfo(dir)
+	s.KubeContext, s.KubeNamespace = kubeContext(home, getenv)
+
+	if s.IsEmpty() {
+		return nil
+	}
+	return s
+}
+
+// IsEmpty reports whether nothing is known about the environment.
+func (s *Snapshot) IsEmpty() bool {
+	return s == nil || *s == Snapshot{}
+}
+
+// Summary returns a short human readable description of the environment e.g "git: main@1a2b3c4, kube: prod/api".
+func (s *Snapshot) Summary() string {
+	if s.IsEmpty() {
+		return ""
+	}
+
+	var parts []string
+	if s.GitBranch != "" || s.GitCommit != "" {
+		git := s.GitBranch
+		if commit := shortCommit(s.GitCommit); commit != "" {
+			if git != "" {
+				git += "@"
+			}
+			git += commit
+		}
+		parts = append(parts, "git: "+git)
+	}
+	if s.KubeContext != "" {
+		kube := s.KubeContext
+		if s.KubeNamespace != "" {
+			kube += "/" + s.KubeNamespace
+		}
+		parts = append(parts, "kube: "+kube)
+	}
+	if s.AWSProfile != "" {
+		parts = append(parts, "aws: "+s.AWSProfile)
+	}
+	if s.GCloudProject != "" {
+		parts = append(parts, "gcloud: "+s.GCloudProject)
+	}
+	if s.Virtualenv != "" {
+		parts = append(parts, "venv: "+s.Virtualenv)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Preflight returns the checks to make before running commands recorded in the given environments.
+// Each check describes the environment the first command that depends on it ran against.
+func Preflight(snapshots []*Snapshot) []string {
+	var first Snapshot
+	for _, s := range snapshots {
+		if s.IsEmpty() {
+			continue
+		}
+		if first.GitRepo == "" && first.GitBranch == "" && first.GitCommit == "" {
+			first.GitRepo, first.GitBranch, first.GitCommit = s.GitRepo, s.GitBranch, s.GitCommit
+		}
+		if first.KubeContext == "" {
+			first.KubeContext, first.KubeNamespace = s.KubeContext, s.KubeNamespace
+		}
+		first.AWSProfile = firstNonEmpty(first.AWSProfile, s.AWSProfile)
+		first.GCloudProject = firstNonEmpty(first.GCloudProject, s.GCloudProject)
+		first.Virtualenv = firstNonEmpty(first.Virtualenv, s.Virtualenv)
+	}
+
+	var hints []string
+	if first.GitRepo != "" || first.GitBranch != "" {
+		hint := "Run the commands in a checkout of " + firstNonEmpty(first.GitRepo, "the git repository")
+		if first.GitBranch != "" {
+			hint += fmt.Sprintf(" on branch %s", first.GitBranch)
+		}
+		if commit := shortCommit(first.GitCommit); commit != "" {
+			hint += fmt.Sprintf(" (recorded at commit %s)", commit)
+		}
+		hints = append(hints, hint)
+	}
+	if first.KubeContext != "" {
+		hint := fmt.Sprintf("Use the kubectl context %s: `kubectl config use-context %s`", first.KubeContext, first.KubeContext)
+		if first.KubeNamespace != "" {
+			hint += fmt.Sprintf(" with the namespace %s: `kubectl config set-context --current --namespace=%s`", first.KubeNamespace, first.KubeNamespace)
+		}
+		hints = append(hints, hint)
+	}
+	if first.AWSProfile != "" {
+		hints = append(hints, fmt.Sprintf("Use the AWS profile %s: `export AWS_PROFILE=%s`", first.AWSProfile, first.AWSProfile))
+	}
+	if first.GCloudProject != "" {
+		hints = append(hints, fmt.Sprintf("Use the gcloud project %s: `gcloud config set project %s`", first.GCloudProject, first.GCloudProject))
+	}
+	if first.Virtualenv != "" {
+		hints = append(hints, fmt.Sprintf("Activate the Python virtualenv %s", first.Virtualenv))
+	}
+	return hints
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+func virtualenv(getenv func(string) string) string {
+	if venv := getenv("VIRTUAL_ENV"); venv != "" {
+		name := filepath.Base(venv)
+		// venvs are commonly called .venv or venv, so the project directory is more telling.
+		if name == ".venv" || name == "venv" {
+			name = filepath.Join(filepath.Base(filepath.Dir(venv)), name)
+		}
+		return name
+	}
+	if conda := getenv("CONDA_DEFAULT_ENV"); conda != "" && conda != "base" {
+		return conda
+	}
+	return ""
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
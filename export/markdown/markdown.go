@@ -5,11 +5,13 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"text/template"
 	"time"
 
 	"github.com/atotto/clipboard"
 	"github.com/getsavvyinc/savvy-cli/display"
+	"github.com/getsavvyinc/savvy-cli/envcontext"
 	"github.com/getsavvyinc/savvy-cli/extension"
 	"github.com/getsavvyinc/savvy-cli/slice"
 )
@@ -26,27 +28,131 @@ const MdTemplate = `I used [Savvy]({{ .URL }}) to record these{{ if gt (len .Lin
 ----
 {{- end -}}
 
-{{- range $i, $command := .Commands }}
+{{- if gt (len .Preflight) 0 }}
+
+### Before You Start
+{{- range .Preflight }}
+* {{ . }}
+{{- end }}
+----
+{{- end -}}
+
+{{- range $command := .Commands }}
+{{- if $command.Note }}
+
+{{ quote $command.Note }}
+{{- else }}
+{{- if $command.Metadata }}
+
+_{{ $command.Metadata }}_
+{{- end }}
 
  ~~~sh
- {{ add $i 1 }}. {{ $command }}
+ {{ $command.Number }}. {{ $command.Command }}
+ ~~~
+{{- if $command.Patch }}
+
+ ~~~diff
+{{ $command.Patch }}
  ~~~
+{{- end }}
+{{- if $command.SubSteps }}
+
+Entered in the interactive session:
+
+ ~~~
+{{ lines $command.SubSteps }}
+ ~~~
+{{- end }}
+{{- if $command.Output }}
+
+Expected output:
+
+ ~~~
+{{ $command.Output }}
+ ~~~
+{{- end }}
+{{- end }}
 
 {{- printf "\n" -}}
 {{- end -}}
 `
 
+// Command is a single recorded step rendered in the markdown file.
+type Command struct {
+	Command    string
+	Output     string
+	StartedAt  time.Time
+	Duration   time.Duration
+	WorkingDir string
+	// Note is a note the user added between commands. Notes are rendered as quotes instead of numbered commands.
+	Note string
+	// Patch is the diff of a file recorded with savvy record file, if the file changed during the recording.
+	Patch string
+	// SubSteps are the lines entered in the interactive session e.g psql that the command started.
+	SubSteps []string
+	// Context is the environment e.g git branch or kube context the command ran against.
+	Context *envcontext.Snapshot
+
+	number int
+}
+
+// Number returns the position of the command among the commands, notes excluded.
+func (c Command) Number() int {
+	return c.number
+}
+
+func numberCommands(commands []Command) []Command {
+	numbered := make([]Command, len(commands))
+	n := 0
+	for i, c := range commands {
+		if c.Note == "" {
+			n++
+		}
+		c.number = n
+		numbered[i] = c
+	}
+	return numbered
+}
+
+// Metadata returns a short human readable summary of when, where and for how long the command ran.
+func (c Command) Metadata() string {
+	var parts []string
+	if !c.StartedAt.IsZero() {
+		parts = append(parts, "started at "+c.StartedAt.Format(time.DateTime))
+	}
+	if c.Duration > 0 {
+		parts = append(parts, "took "+c.Duration.Round(time.Millisecond).String())
+	}
+	if c.WorkingDir != "" {
+		parts = append(parts, "in "+c.WorkingDir)
+	}
+	if summary := c.Context.Summary(); summary != "" {
+		parts = append(parts, summary)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// quote formats text as a markdown block quote.
+func quote(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("> "+line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
 type Service interface {
-	ToMarkdownFile(ctx context.Context, commands []string, links []extension.HistoryItem) error
+	ToMarkdownFile(ctx context.Context, commands []Command, links []extension.HistoryItem) error
 }
 
 var mdTemplate *template.Template
 
 func init() {
 	mdTemplate = template.Must(template.New("md").Funcs(template.FuncMap{
-		"add": func(a, b int) int {
-			return a + b
-		}}).Parse(MdTemplate))
+		"quote": quote,
+		"lines": func(lines []string) string { return strings.Join(lines, "\n") },
+	}).Parse(MdTemplate))
 }
 
 type svc struct {
@@ -64,14 +170,18 @@ type TitleURL struct {
 	URL   string
 }
 
-func (s *svc) ToMarkdownFile(ctx context.Context, commands []string, links []extension.HistoryItem) error {
+func (s *svc) ToMarkdownFile(ctx context.Context, commands []Command, links []extension.HistoryItem) error {
 	data := struct {
-		Commands []string
-		URL      string
-		Links    []TitleURL
+		Commands  []Command
+		URL       string
+		Links     []TitleURL
+		Preflight []string
 	}{
 		URL:      s.url,
-		Commands: commands,
+		Commands: numberCommands(commands),
+		Preflight: envcontext.Preflight(slice.Map(commands, func(c Command) *envcontext.Snapshot {
+			return c.Context
+		})),
 		Links: slice.Map(links, func(item extension.HistoryItem) TitleURL {
 			return TitleURL{Title: item.Title, URL: item.URL}
 		}),
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/huh"
@@ -17,6 +18,7 @@ import (
 	"github.com/getsavvyinc/savvy-cli/server"
 	"github.com/getsavvyinc/savvy-cli/slice"
 	"github.com/muesli/termenv"
+	"golang.org/x/term"
 )
 
 type Exporter interface {
@@ -42,6 +44,14 @@ func (e *exporter) Export(ctx context.Context) error {
 		return err
 	}
 
+	if len(terminals(e.commands)) > 1 {
+		layout, err := selectLayout(term.IsTerminal(int(os.Stdin.Fd())))
+		if err != nil {
+			return err
+		}
+		e.commands = arrangeByTerminal(e.commands, layout)
+	}
+
 	switch exportFormat {
 	case MarkdownFile:
 		return e.toMarkdownFile(ctx)
@@ -68,8 +78,21 @@ type exporter struct {
 }
 
 func (e *exporter) toMarkdownFile(ctx context.Context) error {
-	commands := slice.Map(e.commands, func(rc *server.RecordedCommand) string {
-		return rc.Command
+	commands := slice.Map(e.commands, func(rc *server.RecordedCommand) markdown.Command {
+		mc := markdown.Command{
+			Command:    rc.Command,
+			Output:     rc.Output,
+			StartedAt:  rc.StartedAt,
+			Duration:   rc.Duration,
+			WorkingDir: rc.WorkingDir,
+			Note:       rc.Note,
+			Context:    rc.Context,
+			SubSteps:   rc.SubSteps,
+		}
+		if rc.FileInfo != nil && rc.FileInfo.Type == server.FileTypePatch {
+			mc.Patch = strings.TrimSuffix(string(rc.FileInfo.Content), "\n")
+		}
+		return mc
 	})
 
 	return e.mdSvc.ToMarkdownFile(ctx, commands, e.links)
@@ -0,0 +1,109 @@
+package export
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/huh"
+	"github.com/getsavvyinc/savvy-cli/server"
+)
+
+const (
+	// Interleave keeps the steps of all terminals in the order they ran.
+	Interleave = "interleave"
+	// GroupByTerminal lists the steps of each terminal together.
+	GroupByTerminal = "group"
+)
+
+// mainTerminal labels the terminal that started the recording session.
+const mainTerminal = "main terminal"
+
+func terminalLabel(cmd *server.RecordedCommand) string {
+	if cmd.Terminal == "" {
+		return mainTerminal
+	}
+	return cmd.Terminal
+}
+
+// terminals returns the labels of the terminals steps were recorded in, in the order they were first used.
+func terminals(cmds []*server.RecordedCommand) []string {
+	seen := make(map[string]bool)
+	var labels []string
+	for _, cmd := range cmds {
+		label := terminalLabel(cmd)
+		if !seen[label] {
+			seen[label] = true
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
+// selectLayout asks the user how to lay out steps recorded in multiple terminals.
+// Without a terminal to ask in, the steps are interleaved in the order they ran.
+func selectLayout(interactive bool) (string, error) {
+	if !interactive {
+		return Interleave, nil
+	}
+
+	var layout string
+	if err := huh.NewSelect[string]().
+		Title("Steps Were Recorded in Multiple Terminals").
+		Description("Select how to lay out the steps").
+		Options(
+			huh.NewOption("Interleave steps in the order they ran", Interleave),
+			huh.NewOption("Group steps by terminal", GroupByTerminal),
+		).Value(&layout).Run(); err != nil {
+		return "", err
+	}
+	return layout, nil
+}
+
+// arrangeByTerminal lays out steps recorded in multiple terminals.
+// A note step that names the terminal is added whenever the terminal changes.
+func arrangeByTerminal(cmds []*server.RecordedCommand, layout string) []*server.RecordedCommand {
+	if len(terminals(cmds)) < 2 {
+		return cmds
+	}
+
+	ordered := cmds
+	if layout == GroupByTerminal {
+		ordered = nil
+		for _, label := range terminals(cmds) {
+			for _, cmd := range cmds {
+				if terminalLabel(cmd) == label {
+					ordered = append(ordered, cmd)
+				}
+			}
+		}
+	}
+
+	var arranged []*server.RecordedCommand
+	var current string
+	for _, cmd := range ordered {
+		if label := terminalLabel(cmd); label != current {
+			// When steps are interleaved, the reader has to switch back and forth between terminals.
+			switchTerminal := layout == Interleave && current != ""
+			current = label
+			arranged = append(arranged, terminalNote(cmd, switchTerminal))
+		}
+		arranged = append(arranged, cmd)
+	}
+	return arranged
+}
+
+func terminalNote(cmd *server.RecordedCommand, switchTerminal bool) *server.RecordedCommand {
+	terminal := "the " + mainTerminal
+	if cmd.Terminal != "" {
+		terminal = "the terminal " + cmd.Terminal
+	}
+
+	note := fmt.Sprintf("In %s:", terminal)
+	if switchTerminal {
+		note = fmt.Sprintf("Switch to %s:", terminal)
+	}
+	return &server.RecordedCommand{
+		Note:      note,
+		StartedAt: cmd.StartedAt,
+		Terminal:  cmd.Terminal,
+	}
+}
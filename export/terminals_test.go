@@ -0,0 +1,74 @@
+package export
+
+import (
+	"testing"
+	"time"
+
+	"github.com/getsavvyinc/savvy-cli/server"
+	"github.com/getsavvyinc/savvy-cli/slice"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArrangeByTerminal(t *testing.T) {
+	start := time.Now()
+	cmds := []*server.RecordedCommand{
+		{Command: "kubectl scale deploy/api --replicas=0", StartedAt: start},
+		{Command: "psql -c 'select 1'", Terminal: "db", StartedAt: start.Add(time.Second)},
+		{Command: "kubectl scale deploy/api --replicas=3", StartedAt: start.Add(2 * time.Second)},
+	}
+
+	steps := func(cmds []*server.RecordedCommand) []string {
+		return slice.Map(cmds, func(c *server.RecordedCommand) string {
+			if c.IsNote() {
+				return "# " + c.Note
+			}
+			return c.Command
+		})
+	}
+
+	testCases := []struct {
+		name     string
+		layout   string
+		expected []string
+	}{
+		{
+			name:   "interleave",
+			layout: Interleave,
+			expected: []string{
+				"# In the main terminal:",
+				"kubectl scale deploy/api --replicas=0",
+				"# Switch to the terminal db:",
+				"psql -c 'select 1'",
+				"# Switch to the main terminal:",
+				"kubectl scale deploy/api --replicas=3",
+			},
+		},
+		{
+			name:   "group",
+			layout: GroupByTerminal,
+			expected: []string{
+				"# In the main terminal:",
+				"kubectl scale deploy/api --replicas=0",
+				"kubectl scale deploy/api --replicas=3",
+				"# In the terminal db:",
+				"psql -c 'select 1'",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, steps(arrangeByTerminal(cmds, tc.layout)))
+		})
+	}
+
+	t.Run("single terminal", func(t *testing.T) {
+		assert.Equal(t, cmds[:1], arrangeByTerminal(cmds[:1], GroupByTerminal))
+	})
+}
+
+func TestSelectLayoutWithoutTerminal(t *testing.T) {
+	layout, err := selectLayout(false)
+	assert.NoError(t, err)
+	assert.Equal(t, Interleave, layout)
+}
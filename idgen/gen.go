@@ -8,6 +8,7 @@ import (
 const (
 	CommandPrefix = "cmd-"
 	FilePrefix    = "f-"
+	NotePrefix    = "n-"
 	LLMTagPrefix  = "llm-"
 )
 
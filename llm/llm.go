@@ -10,6 +10,10 @@ type StepTypeEnum string
 const (
 	StepTypeCode StepTypeEnum = "code"
 	StepTypeFile StepTypeEnum = "file"
+	// StepTypeNote is a note the user added while recording. Note steps have a description but no command.
+	StepTypeNote StepTypeEnum = "note"
+	// StepTypeInteractive is a command that starts an interactive session e.g psql. SubSteps are the lines entered in the session.
+	StepTypeInteractive StepTypeEnum = "interactive"
 )
 
 type RunbookStep struct {
@@ -17,4 +21,5 @@ type RunbookStep struct {
 	Description string       `json:"description"`
 	Command     string       `json:"command"`
 	CommandID   string       `json:"command_id"`
+	SubSteps    []string     `json:"sub_steps,omitempty"`
 }
@@ -18,6 +18,7 @@ import (
 	"github.com/getsavvyinc/savvy-cli/idgen"
 	"github.com/getsavvyinc/savvy-cli/llm"
 	"github.com/getsavvyinc/savvy-cli/model"
+	"github.com/getsavvyinc/savvy-cli/server"
 	"github.com/getsavvyinc/savvy-cli/slice"
 	"github.com/sashabaranov/go-openai"
 	"github.com/sashabaranov/go-openai/jsonschema"
@@ -47,9 +48,13 @@ func newCustomService(cfg *config.Config) Service {
 func hardcodedRunbook(commands []*CommandAndID) *llm.Runbook {
 	steps := make([]llm.RunbookStep, len(commands))
 	for i, c := range commands {
-		steps[i] = llm.RunbookStep{
-			Command: c.Command,
+		if c.Note != "" {
+			steps[i] = noteStep(c)
+			continue
 		}
+		steps[i] = withSubSteps(llm.RunbookStep{
+			Command: c.Command,
+		}, c)
 	}
 	return &llm.Runbook{
 		Title: "Savvy Runbook",
@@ -60,7 +65,14 @@ func hardcodedRunbook(commands []*CommandAndID) *llm.Runbook {
 func (c *customSvc) GenerateRunbook(ctx context.Context, commands []model.RecordedCommand) (*llm.Runbook, error) {
 
 	taggedCommands := slice.Map(commands, func(step model.RecordedCommand) *CommandAndID {
-		return &CommandAndID{Command: step.Command, CommandID: idgen.New(idgen.LLMTagPrefix)}
+		return &CommandAndID{
+			Command:   step.Command,
+			CommandID: idgen.New(idgen.LLMTagPrefix),
+			Output:    server.TruncateOutputTo(step.Output, maxPromptOutputSize, "..."),
+			Note:      step.Note,
+			Context:   step.Context.Summary(),
+			SubSteps:  step.SubSteps,
+		}
 	})
 
 	runbook, err := c.generateRunbookTitleAndDescriptions(ctx, taggedCommands)
@@ -81,13 +93,18 @@ func (c *customSvc) GenerateRunbook(ctx context.Context, commands []model.Record
 	// NOTE: This is required since the LLM may return descriptions out of order or with some commands missing
 	// We need to ensure that the descriptions are matched to the correct command
 	for _, command := range taggedCommands {
+		// Notes are written by the user, so they are kept as is.
+		if command.Note != "" {
+			resultSteps = append(resultSteps, noteStep(command))
+			continue
+		}
 		if step, ok := stepByID[command.CommandID]; ok {
-			resultSteps = append(resultSteps, step)
+			resultSteps = append(resultSteps, withSubSteps(step, command))
 		} else {
-			resultSteps = append(resultSteps, llm.RunbookStep{
+			resultSteps = append(resultSteps, withSubSteps(llm.RunbookStep{
 				Command:   command.Command,
 				CommandID: command.CommandID,
-			})
+			}, command))
 		}
 	}
 
@@ -103,9 +120,34 @@ const (
 
 command_id:command
 {{range .Commands}}
+{{- if .Note}}
+note from the user: {{.Note}}
+{{- else}}
 {{.CommandID}}:{{.Command}}
+{{- end}}
+{{- if .Context}}
+environment of {{.CommandID}}: {{.Context}}
+{{- end}}
+{{- if .SubSteps}}
+lines entered in the interactive session started by {{.CommandID}}:
+{{- range .SubSteps}}
+{{.}}
+{{- end}}
+{{- end}}
+{{- if .Output}}
+output of {{.CommandID}}:
+{{.Output}}
+{{- end}}
 {{end}}
 
+Some commands are followed by the environment they ran against e.g the git branch, kubectl context or cloud account. Mention the environment in the description when the command depends on it.
+
+Some commands start an interactive session e.g psql or python and are followed by the lines the user entered in it. Describe what the session does as a whole in the description of the command.
+
+Some commands are followed by the output they produced. Use the output to write more accurate descriptions, but never copy the output into the command field.
+
+The user may have added notes between commands. Notes are not commands and do not have a command_id. Use them to understand the purpose of the commands around them and to write the Title, but do not generate steps for them.
+
 You will generate the Title for the runbook and a meaningful description for each command in the runbook.
 
 The Title must be a short single sentences tha begins with the phrase: "How To". The title must be short and concise and must describe the purpose of the runbook. Do not make the title overly general.
@@ -302,8 +344,38 @@ var (
 type CommandAndID struct {
 	Command   string `json:"command,omitempty"`
 	CommandID string `json:"command_id,omitempty"`
+	// Output is the (truncated) output of the command. It is only used to give the llm more context.
+	Output string `json:"-"`
+	// Note is set instead of Command for notes the user added while recording.
+	Note string `json:"-"`
+	// Context describes the environment e.g git branch or kube context the command ran against.
+	Context string `json:"-"`
+	// SubSteps are the lines entered in the interactive session e.g psql that the command started.
+	SubSteps []string `json:"-"`
 }
 
+func noteStep(c *CommandAndID) llm.RunbookStep {
+	return llm.RunbookStep{
+		Type:        llm.StepTypeNote,
+		Description: c.Note,
+		CommandID:   c.CommandID,
+	}
+}
+
+// withSubSteps turns step into an interactive session step if the command started one.
+// The llm only describes the session, so the lines entered in it are taken from the recording.
+func withSubSteps(step llm.RunbookStep, c *CommandAndID) llm.RunbookStep {
+	if len(c.SubSteps) == 0 {
+		return step
+	}
+	step.Type = llm.StepTypeInteractive
+	step.SubSteps = c.SubSteps
+	return step
+}
+
+// maxPromptOutputSize limits how much of a command's output is included in the prompt.
+const maxPromptOutputSize = 1024
+
 func (c *customSvc) generateRunbookTitleAndDescriptions(ctx context.Context, commands []*CommandAndID) (*llm.Runbook, error) {
 	buf := new(bytes.Buffer)
 	if err := generateRunbookTitleAndDescriptionsPromptTemplate.Execute(buf, struct {
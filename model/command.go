@@ -1,15 +1,31 @@
 package model
 
-import "io/fs"
+import (
+	"io/fs"
+	"time"
+
+	"github.com/getsavvyinc/savvy-cli/envcontext"
+)
 
 type RecordedCommand struct {
-	Command  string    `json:"command"`
-	Prompt   string    `json:"prompt,omitempty"`
-	FileInfo *FileInfo `json:"file_info,omitempty"`
+	Command    string        `json:"command"`
+	Prompt     string        `json:"prompt,omitempty"`
+	Output     string        `json:"output,omitempty"`
+	StartedAt  time.Time     `json:"started_at"`
+	Duration   time.Duration `json:"duration,omitempty"`
+	WorkingDir string        `json:"working_dir,omitempty"`
+	FileInfo   *FileInfo     `json:"file_info,omitempty"`
+	Note       string        `json:"note,omitempty"`
+	// Context is the environment the command ran against e.g the git branch or kube context.
+	Context *envcontext.Snapshot `json:"context,omitempty"`
+	// SubSteps are the lines entered in the interactive session e.g psql or python that the command started.
+	SubSteps []string `json:"sub_steps,omitempty"`
 }
 
 type FileInfo struct {
 	Mode    fs.FileMode `json:"mode,omitempty"`
 	Content []byte      `json:"content,omitempty"`
 	Path    string      `json:"path,omitempty"`
+	// Type is "directory" for a gzipped tarball of a directory and "patch" for a unified diff. It is empty for files.
+	Type string `json:"type,omitempty"`
 }
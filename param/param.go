@@ -1,24 +1,339 @@
 package param
 
-import "regexp"
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
 
-var paramRegex = regexp.MustCompile(`<([a-zA-Z0-9-_]+)>`)
+// Type constrains the values of a param.
+type Type string
 
-func Extract(input string) []string {
-	// Define a regular expression to match parameters in the form of <alphanumericchars>
-	matches := paramRegex.FindAllStringSubmatch(input, -1)
-
-	seen := make(map[string]struct{})
-	// Extract the matched parameters
-	var params []string
-	for _, match := range matches {
-		if len(match) >= 1 {
-			if _, ok := seen[match[0]]; ok {
-				continue
+const (
+	TypeString Type = ""
+	TypeInt    Type = "int"
+	TypePort   Type = "port"
+	TypeURL    Type = "url"
+	// TypeEnum params take one of the choices listed in the placeholder e.g <env:enum(dev|staging|prod)>.
+	TypeEnum Type = "enum"
+	// TypeRegex params must match the regular expression in the placeholder e.g <tag:regex(v[0-9]+)>.
+	TypeRegex Type = "regex"
+	// TypeCommand params take one of the lines printed by the shell command in the placeholder
+	// e.g <pod:cmd(kubectl get pods -o name)>. The command can use other params e.g <namespace>.
+	TypeCommand Type = "cmd"
+)
+
+// Param is a placeholder in a command.
+//
+// The simplest placeholder is <name>. Placeholders can also set a type, a default, a description and mark the value as
+// secret:
+//
+//	<name[!][:type][=default][ # description]>
+//
+// e.g <port:port=5432 # Port the database listens on>, <env:enum(dev|prod)=dev> or <token! # API token>.
+type Param struct {
+	Name string
+	Type Type
+	// Choices are the values an enum param can take.
+	Choices []string
+	// Pattern is the regular expression the value of a regex param must match.
+	Pattern *regexp.Regexp
+	// Command is the shell command that prints the choices of a cmd param, one per line.
+	Command     string
+	Default     string
+	HasDefault  bool
+	Description string
+	// Secret is true if the value must not be displayed while it is entered.
+	Secret bool
+}
+
+// Key returns the key the value of the param is stored under e.g <port> for <port:port=5432>.
+func (p Param) Key() string {
+	return "<" + p.Name + ">"
+}
+
+var (
+	ErrInvalidInt   = errors.New("must be a number")
+	ErrInvalidPort  = errors.New("must be a port between 1 and 65535")
+	ErrInvalidURL   = errors.New("must be a url e.g https://example.com")
+	ErrInvalidEnum  = errors.New("must be one of the choices")
+	ErrInvalidRegex = errors.New("must match the pattern")
+)
+
+// Validate returns an error if value isn't valid for the type of the param.
+func (p Param) Validate(value string) error {
+	switch p.Type {
+	case TypeInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return ErrInvalidInt
+		}
+	case TypePort:
+		if port, err := strconv.Atoi(value); err != nil || port < 1 || port > 65535 {
+			return ErrInvalidPort
+		}
+	case TypeURL:
+		if u, err := url.Parse(value); err != nil || u.Scheme == "" || u.Host == "" {
+			return ErrInvalidURL
+		}
+	case TypeEnum:
+		for _, choice := range p.Choices {
+			if value == choice {
+				return nil
 			}
-			seen[match[0]] = struct{}{}
-			params = append(params, match[0])
+		}
+		return fmt.Errorf("%w: %s", ErrInvalidEnum, strings.Join(p.Choices, ", "))
+	case TypeRegex:
+		if !p.Pattern.MatchString(value) {
+			return fmt.Errorf("%w %s", ErrInvalidRegex, p.pattern())
 		}
 	}
+	return nil
+}
+
+// Hint describes the values the param takes e.g "a port between 1 and 65535". It is empty for string params.
+func (p Param) Hint() string {
+	switch p.Type {
+	case TypeInt:
+		return "a number"
+	case TypePort:
+		return "a port between 1 and 65535"
+	case TypeURL:
+		return "a url e.g https://example.com"
+	case TypeEnum:
+		return "one of " + strings.Join(p.Choices, ", ")
+	case TypeRegex:
+		return "a value that matches " + p.pattern()
+	case TypeCommand:
+		return "one of the lines printed by " + p.Command
+	}
+	return ""
+}
+
+// pattern returns the regular expression as it was written in the placeholder.
+func (p Param) pattern() string {
+	return strings.TrimSuffix(strings.TrimPrefix(p.Pattern.String(), "^(?:"), ")$")
+}
+
+// merge fills in the fields of p that other sets and p doesn't e.g a default that's only set in one of the placeholders.
+func (p Param) merge(other Param) Param {
+	if p.Type == TypeString && other.Type != TypeString {
+		p.Type, p.Choices, p.Pattern, p.Command = other.Type, other.Choices, other.Pattern, other.Command
+	}
+	if !p.HasDefault && other.HasDefault {
+		p.Default, p.HasDefault = other.Default, true
+	}
+	if p.Description == "" {
+		p.Description = other.Description
+	}
+	p.Secret = p.Secret || other.Secret
+	return p
+}
+
+// Parse returns the params in input, in the order they're first used.
+// A param used more than once is returned once, with the details of all its placeholders.
+// The params used by the command of a cmd param come before the cmd param, since they must be set first.
+func Parse(input string) []Param {
+	var params []Param
+	index := make(map[string]int)
+	var add func(p Param)
+	add = func(p Param) {
+		if p.Type == TypeCommand {
+			for _, nested := range Parse(p.Command) {
+				add(nested)
+			}
+		}
+		if i, ok := index[p.Name]; ok {
+			params[i] = params[i].merge(p)
+			return
+		}
+		index[p.Name] = len(params)
+		params = append(params, p)
+	}
+	scan(input, func(_, _ int, p Param) { add(p) })
 	return params
 }
+
+// Extract returns the keys of the params in input e.g <port> for <port:port=5432>.
+func Extract(input string) []string {
+	var keys []string
+	for _, p := range Parse(input) {
+		keys = append(keys, p.Key())
+	}
+	return keys
+}
+
+// Replace replaces the placeholders in input that have a value. Values are keyed by Param.Key.
+func Replace(input string, values map[string]string) string {
+	if len(values) == 0 {
+		return input
+	}
+
+	var b strings.Builder
+	last := 0
+	scan(input, func(start, end int, p Param) {
+		value, ok := values[p.Key()]
+		if !ok && p.Type == TypeCommand {
+			// set the params used by the command that prints the choices.
+			value, ok = "<"+Replace(input[start+1:end-1], values)+">", true
+		}
+		if !ok {
+			return
+		}
+		b.WriteString(input[last:start])
+		b.WriteString(value)
+		last = end
+	})
+	b.WriteString(input[last:])
+	return b.String()
+}
+
+// scan calls fn with the position of every placeholder in input.
+func scan(input string, fn func(start, end int, p Param)) {
+	for i := 0; i < len(input); i++ {
+		if input[i] != '<' {
+			continue
+		}
+		p, n, ok := parsePlaceholder(input[i:])
+		if !ok {
+			continue
+		}
+		fn(i, i+n, p)
+		i += n - 1
+	}
+}
+
+func isNameChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_'
+}
+
+// parsePlaceholder parses the placeholder at the start of s and returns its length.
+func parsePlaceholder(s string) (Param, int, bool) {
+	var p Param
+
+	i := 1
+	for i < len(s) && isNameChar(s[i]) {
+		i++
+	}
+	if i == 1 {
+		return p, 0, false
+	}
+	p.Name = s[1:i]
+
+	if i < len(s) && s[i] == '!' {
+		p.Secret = true
+		i++
+	}
+
+	if i < len(s) && s[i] == ':' {
+		n, ok := parseType(s[i+1:], &p)
+		if !ok {
+			return p, 0, false
+		}
+		i += 1 + n
+	}
+
+	if i < len(s) && s[i] == '=' {
+		end := strings.IndexAny(s[i+1:], "#>\n")
+		if end < 0 {
+			return p, 0, false
+		}
+		p.Default = strings.TrimSpace(s[i+1 : i+1+end])
+		p.HasDefault = true
+		i += 1 + end
+	}
+
+	// a description is separated from the rest of the placeholder by whitespace and #.
+	j := i
+	for j < len(s) && s[j] == ' ' {
+		j++
+	}
+	if j < len(s) && s[j] == '#' {
+		end := strings.IndexAny(s[j+1:], ">\n")
+		if end < 0 {
+			return p, 0, false
+		}
+		p.Description = strings.TrimSpace(s[j+1 : j+1+end])
+		i = j + 1 + end
+	}
+
+	if i >= len(s) || s[i] != '>' {
+		return p, 0, false
+	}
+	if p.HasDefault && p.Validate(p.Default) != nil {
+		return p, 0, false
+	}
+	return p, i + 1, true
+}
+
+// parseType parses the type at the start of s e.g port or enum(dev|prod) and returns its length.
+func parseType(s string, p *Param) (int, bool) {
+	i := 0
+	for i < len(s) && s[i] >= 'a' && s[i] <= 'z' {
+		i++
+	}
+	p.Type = Type(s[:i])
+
+	switch p.Type {
+	case TypeInt, TypePort, TypeURL:
+		return i, true
+	case TypeEnum, TypeRegex, TypeCommand:
+	default:
+		return 0, false
+	}
+
+	arg, n, ok := parseArg(s[i:])
+	if !ok {
+		return 0, false
+	}
+	i += n
+
+	if p.Type == TypeCommand {
+		p.Command = strings.TrimSpace(arg)
+		return i, p.Command != ""
+	}
+
+	if p.Type == TypeEnum {
+		for _, choice := range strings.Split(arg, "|") {
+			if choice = strings.TrimSpace(choice); choice != "" {
+				p.Choices = append(p.Choices, choice)
+			}
+		}
+		return i, len(p.Choices) > 0
+	}
+
+	// the whole value must match the pattern.
+	pattern, err := regexp.Compile("^(?:" + arg + ")$")
+	if err != nil {
+		return 0, false
+	}
+	p.Pattern = pattern
+	return i, true
+}
+
+// parseArg parses the parenthesized argument at the start of s. Parentheses in the argument must be balanced or escaped.
+func parseArg(s string) (string, int, bool) {
+	if len(s) == 0 || s[0] != '(' {
+		return "", 0, false
+	}
+
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return s[1:i], i + 1, true
+			}
+		case '\n':
+			return "", 0, false
+		}
+	}
+	return "", 0, false
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/getsavvyinc/savvy-cli/param"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestExtractParams(t *testing.T) {
@@ -54,3 +55,125 @@ func TestExtractParams(t *testing.T) {
 		})
 	}
 }
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected []param.Param
+	}{
+		{
+			name:     "plain param",
+			input:    "psql -h <host>",
+			expected: []param.Param{{Name: "host"}},
+		},
+		{
+			name:  "typed param with default and description",
+			input: "psql -p <port:port=5432 # Port the database listens on>",
+			expected: []param.Param{
+				{Name: "port", Type: param.TypePort, Default: "5432", HasDefault: true, Description: "Port the database listens on"},
+			},
+		},
+		{
+			name:  "enum",
+			input: "deploy --env <env:enum(dev|staging|prod)=staging>",
+			expected: []param.Param{
+				{Name: "env", Type: param.TypeEnum, Choices: []string{"dev", "staging", "prod"}, Default: "staging", HasDefault: true},
+			},
+		},
+		{
+			name:  "secret",
+			input: "curl -H 'Authorization: Bearer <token! # API token>'",
+			expected: []param.Param{
+				{Name: "token", Secret: true, Description: "API token"},
+			},
+		},
+		{
+			name:  "details are merged across placeholders",
+			input: "echo <host> && ping <host=localhost # Host to ping>",
+			expected: []param.Param{
+				{Name: "host", Default: "localhost", HasDefault: true, Description: "Host to ping"},
+			},
+		},
+		{
+			name:  "cmd params come after the params their command uses",
+			input: "kubectl logs <pod:cmd(kubectl get pods -n <namespace> -o name)> -n <namespace>",
+			expected: []param.Param{
+				{Name: "namespace"},
+				{Name: "pod", Type: param.TypeCommand, Command: "kubectl get pods -n <namespace> -o name"},
+			},
+		},
+		{
+			name:  "unknown type",
+			input: "echo <name:color>",
+		},
+		{
+			name:  "default of the wrong type",
+			input: "echo <port:port=http>",
+		},
+		{
+			name:  "redirections",
+			input: "sort < input.txt > output.txt",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, param.Parse(tc.input))
+		})
+	}
+
+	t.Run("regex", func(t *testing.T) {
+		params := param.Parse(`git tag <tag:regex(v[0-9]+(\.[0-9]+)*)>`)
+		if assert.Len(t, params, 1) {
+			assert.Equal(t, param.TypeRegex, params[0].Type)
+			assert.NoError(t, params[0].Validate("v1.2"))
+			assert.Error(t, params[0].Validate("1.2"))
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		param   param.Param
+		value   string
+		wantErr error
+	}{
+		{name: "string", param: param.Param{Name: "s"}, value: ""},
+		{name: "int", param: param.Param{Name: "n", Type: param.TypeInt}, value: "12"},
+		{name: "not an int", param: param.Param{Name: "n", Type: param.TypeInt}, value: "twelve", wantErr: param.ErrInvalidInt},
+		{name: "port", param: param.Param{Name: "p", Type: param.TypePort}, value: "8080"},
+		{name: "port out of range", param: param.Param{Name: "p", Type: param.TypePort}, value: "70000", wantErr: param.ErrInvalidPort},
+		{name: "url", param: param.Param{Name: "u", Type: param.TypeURL}, value: "https://example.com/path"},
+		{name: "not a url", param: param.Param{Name: "u", Type: param.TypeURL}, value: "example.com", wantErr: param.ErrInvalidURL},
+		{name: "enum", param: param.Param{Name: "e", Type: param.TypeEnum, Choices: []string{"a", "b"}}, value: "b"},
+		{name: "not a choice", param: param.Param{Name: "e", Type: param.TypeEnum, Choices: []string{"a", "b"}}, value: "c", wantErr: param.ErrInvalidEnum},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.param.Validate(tc.value)
+			if tc.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestReplace(t *testing.T) {
+	input := "psql -h <host> -p <port:port=5432 # Port> -c 'select * from <table>' <host>"
+	values := map[string]string{"<host>": "db", "<port>": "5433"}
+	assert.Equal(t, "psql -h db -p 5433 -c 'select * from <table>' db", param.Replace(input, values))
+
+	t.Run("cmd", func(t *testing.T) {
+		input := "kubectl logs <pod:cmd(kubectl get pods -n <namespace> -o name)> -n <namespace>"
+		values := map[string]string{"<namespace>": "prod"}
+		assert.Equal(t, "kubectl logs <pod:cmd(kubectl get pods -n prod -o name)> -n prod", param.Replace(input, values))
+
+		values["<pod>"] = "pod/api"
+		assert.Equal(t, "kubectl logs pod/api -n prod", param.Replace(input, values))
+	})
+}
@@ -0,0 +1,110 @@
+package param
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of the environment variables that set params e.g SAVVY_PARAM_DB_HOST sets <db-host>.
+const EnvPrefix = "SAVVY_PARAM_"
+
+var nameRegex = regexp.MustCompile(`^[a-zA-Z0-9-_]+$`)
+
+var ErrInvalidName = errors.New("invalid param name")
+
+// Key returns the placeholder for the param name e.g <db-host> for db-host.
+// Names that are already placeholders are returned as is.
+func Key(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if strings.HasPrefix(name, "<") && strings.HasSuffix(name, ">") {
+		name = name[1 : len(name)-1]
+	}
+	if !nameRegex.MatchString(name) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	return "<" + name + ">", nil
+}
+
+// EnvName returns the environment variable that sets the param e.g SAVVY_PARAM_DB_HOST for <db-host>.
+func EnvName(param string) string {
+	name := strings.TrimSuffix(strings.TrimPrefix(param, "<"), ">")
+	name = strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+	return EnvPrefix + name
+}
+
+// ParseAssignments parses name=value assignments into values keyed by placeholder.
+func ParseAssignments(assignments []string) (map[string]string, error) {
+	values := make(map[string]string, len(assignments))
+	for _, assignment := range assignments {
+		name, value, ok := strings.Cut(assignment, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected name=value, got %q", assignment)
+		}
+		key, err := Key(name)
+		if err != nil {
+			return nil, err
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// ReadFile reads param values from a yaml file that maps param names to values e.g
+//
+//	db-host: localhost
+//	port: 5432
+func ReadFile(path string) (map[string]string, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]any
+	if err := yaml.Unmarshal(bs, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for name, v := range raw {
+		key, err := Key(name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		switch v := v.(type) {
+		case nil:
+			values[key] = ""
+		case map[string]any, []any:
+			return nil, fmt.Errorf("%s: the value of %s must be a string, number or boolean", path, name)
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+	return values, nil
+}
+
+// FromEnv returns the values of params that are set with SAVVY_PARAM_<NAME> environment variables.
+func FromEnv(params []string, lookupEnv func(string) (string, bool)) map[string]string {
+	values := make(map[string]string)
+	for _, p := range params {
+		if v, ok := lookupEnv(EnvName(p)); ok {
+			values[p] = v
+		}
+	}
+	return values
+}
+
+// Merge merges values into a single map. Values that come later take precedence.
+func Merge(values ...map[string]string) map[string]string {
+	merged := make(map[string]string)
+	for _, vs := range values {
+		for k, v := range vs {
+			merged[k] = v
+		}
+	}
+	return merged
+}
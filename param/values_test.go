@@ -0,0 +1,87 @@
+package param_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/getsavvyinc/savvy-cli/param"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAssignments(t *testing.T) {
+	testCases := []struct {
+		name        string
+		assignments []string
+		expected    map[string]string
+		wantErr     bool
+	}{
+		{
+			name:        "names with and without brackets",
+			assignments: []string{"db-host=localhost", "<port>=5432"},
+			expected:    map[string]string{"<db-host>": "localhost", "<port>": "5432"},
+		},
+		{
+			name:        "value with equals sign",
+			assignments: []string{"query=a=b"},
+			expected:    map[string]string{"<query>": "a=b"},
+		},
+		{
+			name:        "missing value",
+			assignments: []string{"db-host"},
+			wantErr:     true,
+		},
+		{
+			name:        "invalid name",
+			assignments: []string{"db host=localhost"},
+			wantErr:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			values, err := param.ParseAssignments(tc.assignments)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, values)
+		})
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "params.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte("db-host: localhost\nport: 5432\ndry-run: true\n"), 0600))
+
+	values, err := param.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"<db-host>": "localhost", "<port>": "5432", "<dry-run>": "true"}, values)
+
+	t.Run("nested values", func(t *testing.T) {
+		assert.NoError(t, os.WriteFile(path, []byte("db:\n  host: localhost\n"), 0600))
+		_, err := param.ReadFile(path)
+		assert.Error(t, err)
+	})
+}
+
+func TestFromEnv(t *testing.T) {
+	env := map[string]string{"SAVVY_PARAM_DB_HOST": "localhost", "SAVVY_PARAM_PORT": "5432"}
+	lookupEnv := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	values := param.FromEnv([]string{"<db-host>", "<user>"}, lookupEnv)
+	assert.Equal(t, map[string]string{"<db-host>": "localhost"}, values)
+}
+
+func TestMerge(t *testing.T) {
+	merged := param.Merge(
+		map[string]string{"<a>": "env", "<b>": "env"},
+		map[string]string{"<b>": "file"},
+		nil,
+	)
+	assert.Equal(t, map[string]string{"<a>": "env", "<b>": "file"}, merged)
+}
@@ -0,0 +1,225 @@
+package redact
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Finding is a secret detected in a command or file.
+type Finding struct {
+	// Detector is a human readable name of the detector that found the secret.
+	Detector string
+	// Secret is the sensitive text.
+	Secret string
+	// Placeholder is the suggested replacement for Secret.
+	Placeholder string
+
+	start, end int
+}
+
+// Detector finds secrets in text.
+type Detector interface {
+	Detect(text string) []Finding
+}
+
+// regexDetector reports the text matched by re.
+// If group is non zero, only the text matched by the sub-group is reported.
+type regexDetector struct {
+	name        string
+	re          *regexp.Regexp
+	group       int
+	placeholder string
+}
+
+func (d *regexDetector) Detect(text string) []Finding {
+	var findings []Finding
+	for _, m := range d.re.FindAllStringSubmatchIndex(text, -1) {
+		start, end := m[2*d.group], m[2*d.group+1]
+		if start < 0 {
+			continue
+		}
+		findings = append(findings, Finding{
+			Detector:    d.name,
+			Secret:      text[start:end],
+			Placeholder: d.placeholder,
+			start:       start,
+			end:         end,
+		})
+	}
+	return findings
+}
+
+const (
+	minHighEntropyLength = 24
+	highEntropyThreshold = 4.0
+)
+
+var entropyCandidateRegex = regexp.MustCompile(`[A-Za-z0-9+/_=-]{24,}`)
+
+// entropyDetector reports long random looking strings e.g api keys that don't have a well known format.
+type entropyDetector struct{}
+
+func (d *entropyDetector) Detect(text string) []Finding {
+	var findings []Finding
+	for _, m := range entropyCandidateRegex.FindAllStringIndex(text, -1) {
+		candidate := text[m[0]:m[1]]
+		if !looksRandom(candidate) {
+			continue
+		}
+		findings = append(findings, Finding{
+			Detector:    "high entropy string",
+			Secret:      candidate,
+			Placeholder: "<secret>",
+			start:       m[0],
+			end:         m[1],
+		})
+	}
+	return findings
+}
+
+// looksRandom reports whether s is likely a randomly generated secret.
+// Hex strings (e.g git commit shas) and words separated by dashes are not considered random.
+func looksRandom(s string) bool {
+	if len(s) < minHighEntropyLength {
+		return false
+	}
+
+	var hasUpper, hasLower, hasDigit bool
+	for _, r := range s {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasUpper || !hasLower || !hasDigit {
+		return false
+	}
+	return shannonEntropy(s) >= highEntropyThreshold
+}
+
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+
+	var entropy float64
+	n := float64(len(s))
+	for _, c := range counts {
+		p := float64(c) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// DefaultDetectors are the detectors used by Detect.
+// Detectors earlier in the list take precedence when findings overlap.
+var DefaultDetectors = []Detector{
+	&regexDetector{
+		name:        "AWS access key id",
+		re:          regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA)[0-9A-Z]{16}\b`),
+		placeholder: "<aws-access-key-id>",
+	},
+	&regexDetector{
+		name:        "AWS secret access key",
+		re:          regexp.MustCompile(`(?i)aws_secret_access_key["']?\s*[=:]\s*["']?([A-Za-z0-9/+=]{40})`),
+		group:       1,
+		placeholder: "<aws-secret-access-key>",
+	},
+	&regexDetector{
+		name:        "GitHub token",
+		re:          regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`),
+		placeholder: "<github-token>",
+	},
+	&regexDetector{
+		name:        "Slack token",
+		re:          regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`),
+		placeholder: "<slack-token>",
+	},
+	&regexDetector{
+		name:        "Slack webhook",
+		re:          regexp.MustCompile(`https://hooks\.slack\.com/services/[A-Za-z0-9/]+`),
+		placeholder: "<slack-webhook-url>",
+	},
+	&regexDetector{
+		name:        "JWT",
+		re:          regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{5,}`),
+		placeholder: "<jwt>",
+	},
+	&regexDetector{
+		name:        "bearer token",
+		re:          regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9._~+/-]{8,}=*)`),
+		group:       1,
+		placeholder: "<token>",
+	},
+	&regexDetector{
+		name:        "password in URL",
+		re:          regexp.MustCompile(`\b[a-zA-Z][a-zA-Z0-9+.-]*://[^:/\s@]+:([^@/\s]+)@`),
+		group:       1,
+		placeholder: "<password>",
+	},
+	&regexDetector{
+		name:        "password",
+		re:          regexp.MustCompile(`(?i)(?:--?password[= ]|\b(?:password|passwd|pwd)=)["']?([^\s"']+)`),
+		group:       1,
+		placeholder: "<password>",
+	},
+	&entropyDetector{},
+}
+
+// Detect returns the secrets found in text by the DefaultDetectors ordered by their position in text.
+func Detect(text string) []Finding {
+	return detect(text, DefaultDetectors)
+}
+
+func detect(text string, detectors []Detector) []Finding {
+	var findings []Finding
+	for _, d := range detectors {
+		for _, f := range d.Detect(text) {
+			if overlaps(findings, f) {
+				continue
+			}
+			findings = append(findings, f)
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		return findings[i].start < findings[j].start
+	})
+	return findings
+}
+
+func overlaps(findings []Finding, f Finding) bool {
+	for _, existing := range findings {
+		if f.start < existing.end && existing.start < f.end {
+			return true
+		}
+	}
+	return false
+}
+
+// Secrets replaces the secrets found in text with placeholders.
+// It returns the redacted text and the findings.
+func Secrets(text string) (string, []Finding) {
+	findings := Detect(text)
+	if len(findings) == 0 {
+		return text, nil
+	}
+
+	var b strings.Builder
+	prev := 0
+	for _, f := range findings {
+		b.WriteString(text[prev:f.start])
+		b.WriteString(f.Placeholder)
+		prev = f.end
+	}
+	b.WriteString(text[prev:])
+	return b.String(), findings
+}
@@ -3,8 +3,11 @@ package redact
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/getsavvyinc/savvy-cli/archive"
 	"github.com/getsavvyinc/savvy-cli/server"
 	"github.com/getsavvyinc/savvy-cli/slice"
 	"github.com/getsavvyinc/savvy-cli/theme"
@@ -20,8 +23,34 @@ func Commands(cmds []*server.RecordedCommand) ([]*server.RecordedCommand, error)
 	note := huh.NewNote().Title("Redact Secrets and PII").Description(description)
 	fs = append(fs, note)
 
+	var fileRedactions []*fileRedaction
+	var subStepsRedactions []*subStepsRedaction
+	var outputRedactions []*outputRedaction
 	for i, cmd := range cmds {
-		fs = append(fs, RedactCommand(cmd.Command, strconv.Itoa(i)))
+		redactedCmd, findings := Secrets(stepText(cmd))
+		fs = append(fs, RedactCommand(redactedCmd, strconv.Itoa(i), findings...))
+
+		if redacted, findings := redactSubSteps(cmd.SubSteps); len(findings) > 0 {
+			sr := &subStepsRedaction{cmd: cmd, redactedSubSteps: redacted, replace: true}
+			subStepsRedactions = append(subStepsRedactions, sr)
+			fs = append(fs, sr.confirm(findings))
+		}
+
+		if redacted, findings := Secrets(cmd.Output); len(findings) > 0 {
+			or := &outputRedaction{cmd: cmd, redactedOutput: redacted, replace: true}
+			outputRedactions = append(outputRedactions, or)
+			fs = append(fs, or.confirm(findings))
+		}
+
+		if cmd.FileInfo == nil {
+			continue
+		}
+
+		if redactedContent, findings := redactFile(cmd.FileInfo); len(findings) > 0 {
+			fr := &fileRedaction{fileInfo: cmd.FileInfo, redactedContent: redactedContent, replace: true}
+			fileRedactions = append(fileRedactions, fr)
+			fs = append(fs, fr.confirm(findings))
+		}
 	}
 
 	customTheme := theme.New()
@@ -33,6 +62,16 @@ func Commands(cmds []*server.RecordedCommand) ([]*server.RecordedCommand, error)
 		return nil, err
 	}
 
+	for _, fr := range fileRedactions {
+		fr.apply()
+	}
+	for _, sr := range subStepsRedactions {
+		sr.apply()
+	}
+	for _, or := range outputRedactions {
+		or.apply()
+	}
+
 	for _, f := range fs {
 		in, ok := f.(*huh.Input)
 		if !ok {
@@ -47,15 +86,164 @@ func Commands(cmds []*server.RecordedCommand) ([]*server.RecordedCommand, error)
 		if err != nil {
 			continue
 		}
-		cmds[idx].Command = strVal
+		setStepText(cmds[idx], strVal)
+	}
+
+	return slice.Filter(cmds, isStepKept), nil
+}
+
+// stepText returns the text of a step that the user can redact.
+func stepText(cmd *server.RecordedCommand) string {
+	if cmd.IsNote() {
+		return cmd.Note
+	}
+	return cmd.Command
+}
+
+func setStepText(cmd *server.RecordedCommand, text string) {
+	if cmd.IsNote() {
+		cmd.Note = text
+		return
+	}
+	cmd.Command = text
+}
+
+// isStepKept reports whether a step still has something to export after it was redacted.
+func isStepKept(cmd *server.RecordedCommand) bool {
+	return cmd.Command != "" || cmd.FileInfo != nil || cmd.Note != ""
+}
+
+// Auto replaces the secrets detected in cmds, their output and their recorded files, with placeholders without prompting
+// the user.
+func Auto(cmds []*server.RecordedCommand) []*server.RecordedCommand {
+	for _, cmd := range cmds {
+		cmd.Command, _ = Secrets(cmd.Command)
+		cmd.Note, _ = Secrets(cmd.Note)
+		cmd.SubSteps, _ = redactSubSteps(cmd.SubSteps)
+		cmd.Output, _ = Secrets(cmd.Output)
+		if cmd.FileInfo != nil {
+			content, _ := redactFile(cmd.FileInfo)
+			cmd.FileInfo.Content = []byte(content)
+		}
+	}
+
+	return slice.Filter(cmds, isStepKept)
+}
+
+// redactFile returns the content of a recorded file with the secrets detected in it replaced.
+// Directories are recorded as a tarball, in which the secrets in every text file are replaced.
+func redactFile(fi *server.FileInfo) (string, []Finding) {
+	if fi.IsText() {
+		return Secrets(string(fi.Content))
 	}
 
-	redacted := slice.Filter(cmds, func(cmd *server.RecordedCommand) bool {
-		return cmd.Command != "" || cmd.FileInfo != nil
+	var findings []Finding
+	content, err := archive.RewriteText(fi.Content, func(_, text string) string {
+		redacted, fileFindings := Secrets(text)
+		findings = append(findings, fileFindings...)
+		return redacted
 	})
-	return redacted, nil
+	if err != nil {
+		return string(fi.Content), nil
+	}
+	return string(content), findings
+}
+
+// RedactCommand returns an input that allows users to edit cmd.
+// Findings are secrets that were already replaced in cmd; they are highlighted below the input.
+func RedactCommand(cmd string, key string, findings ...Finding) huh.Field {
+	input := huh.NewInput().Value(&cmd).Key(key)
+	if len(findings) > 0 {
+		input = input.Description(describeFindings(findings))
+	}
+	return input.WithTheme(theme.New())
+}
+
+var findingStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Bold(true)
+
+// describeFindings highlights the secrets that were detected and replaced.
+func describeFindings(findings []Finding) string {
+	var lines []string
+	for _, f := range findings {
+		lines = append(lines, fmt.Sprintf("⚠ replaced %s with %s", f.Detector, f.Placeholder))
+	}
+	return findingStyle.Render(strings.Join(lines, "\n"))
+}
+
+// fileRedaction holds the redacted content of a recorded file until the user confirms the redaction.
+type fileRedaction struct {
+	fileInfo        *server.FileInfo
+	redactedContent string
+	replace         bool
+}
+
+func (fr *fileRedaction) confirm(findings []Finding) huh.Field {
+	return huh.NewConfirm().
+		Title(fmt.Sprintf("Secrets detected in %s", fr.fileInfo.Path)).
+		Description(describeFindings(findings)).
+		Affirmative("Replace with placeholders").
+		Negative("Keep file as is").
+		Value(&fr.replace)
+}
+
+func (fr *fileRedaction) apply() {
+	if fr.replace {
+		fr.fileInfo.Content = []byte(fr.redactedContent)
+	}
 }
 
-func RedactCommand(cmd string, key string) huh.Field {
-	return huh.NewInput().Value(&cmd).Key(key).WithTheme(theme.New())
+// redactSubSteps replaces the secrets detected in the lines entered in an interactive session.
+func redactSubSteps(subSteps []string) ([]string, []Finding) {
+	var redacted []string
+	var findings []Finding
+	for _, line := range subSteps {
+		redactedLine, lineFindings := Secrets(line)
+		redacted = append(redacted, redactedLine)
+		findings = append(findings, lineFindings...)
+	}
+	return redacted, findings
+}
+
+// subStepsRedaction holds the redacted lines of an interactive session until the user confirms the redaction.
+type subStepsRedaction struct {
+	cmd              *server.RecordedCommand
+	redactedSubSteps []string
+	replace          bool
+}
+
+func (sr *subStepsRedaction) confirm(findings []Finding) huh.Field {
+	return huh.NewConfirm().
+		Title(fmt.Sprintf("Secrets detected in the session started by %s", sr.cmd.Command)).
+		Description(describeFindings(findings)).
+		Affirmative("Replace with placeholders").
+		Negative("Keep session as is").
+		Value(&sr.replace)
+}
+
+func (sr *subStepsRedaction) apply() {
+	if sr.replace {
+		sr.cmd.SubSteps = sr.redactedSubSteps
+	}
+}
+
+// outputRedaction holds the redacted output of a command until the user confirms the redaction.
+type outputRedaction struct {
+	cmd            *server.RecordedCommand
+	redactedOutput string
+	replace        bool
+}
+
+func (or *outputRedaction) confirm(findings []Finding) huh.Field {
+	return huh.NewConfirm().
+		Title(fmt.Sprintf("Secrets detected in the output of %s", or.cmd.Command)).
+		Description(describeFindings(findings)).
+		Affirmative("Replace with placeholders").
+		Negative("Keep output as is").
+		Value(&or.replace)
+}
+
+func (or *outputRedaction) apply() {
+	if or.replace {
+		or.cmd.Output = or.redactedOutput
+	}
 }
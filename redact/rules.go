@@ -0,0 +1,276 @@
+package redact
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/getsavvyinc/savvy-cli/archive"
+	"github.com/getsavvyinc/savvy-cli/config"
+	"github.com/getsavvyinc/savvy-cli/server"
+	"github.com/getsavvyinc/savvy-cli/slice"
+	"gopkg.in/yaml.v3"
+)
+
+const rulesFileName = "redact.yaml"
+
+var (
+	// DefaultRulesFilePath holds the redaction rules that apply to every recording.
+	DefaultRulesFilePath = filepath.Join(config.DefaultConfigDir, rulesFileName)
+	// RepoRulesFilePath is the path, relative to the root of a repository, of the redaction rules for that repository.
+	RepoRulesFilePath = filepath.Join(".savvy", rulesFileName)
+)
+
+// Rules are redaction policies that are applied to recorded commands without any user input.
+//
+// Example:
+//
+//	rewrites:
+//	  - pattern: '--token=\S+'
+//	    placeholder: '--token=<token>'
+//	drop_commands:
+//	  - '^vault login'
+//	exclude_files:
+//	  - '.env'
+//	  - '~/.aws/**'
+type Rules struct {
+	// Rewrites replace text that matches a pattern with a placeholder in commands and file contents.
+	Rewrites []Rewrite `yaml:"rewrites"`
+	// DropCommands are regular expressions. Commands that match any of them are removed.
+	DropCommands []string `yaml:"drop_commands"`
+	// ExcludeFiles are glob patterns of files whose contents must never be recorded.
+	// Patterns without a path separator are matched against the file name.
+	ExcludeFiles []string `yaml:"exclude_files"`
+
+	rewrites     []*regexp.Regexp
+	dropCommands []*regexp.Regexp
+	excludeFiles []*regexp.Regexp
+}
+
+type Rewrite struct {
+	Pattern     string `yaml:"pattern"`
+	Placeholder string `yaml:"placeholder"`
+}
+
+// LoadRules loads the user's redaction rules and the rules of the repository that contains the current directory.
+// It is not an error if neither file exists.
+func LoadRules() (*Rules, error) {
+	rules := &Rules{}
+
+	paths := []string{DefaultRulesFilePath}
+	if repoRules, ok := findRepoRules(); ok {
+		paths = append(paths, repoRules)
+	}
+
+	for _, path := range paths {
+		r, err := loadRulesFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction rules in %s: %w", path, err)
+		}
+		rules.merge(r)
+	}
+
+	if err := rules.compile(); err != nil {
+		return nil, fmt.Errorf("invalid redaction rules: %w", err)
+	}
+	return rules, nil
+}
+
+// findRepoRules walks up from the current directory until it finds a redaction rules file or the root of a git repository.
+func findRepoRules() (string, bool) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", false
+	}
+
+	for {
+		candidate := filepath.Join(dir, RepoRulesFilePath)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, true
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return "", false
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+func loadRulesFile(path string) (*Rules, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var r Rules
+	if err := yaml.Unmarshal(bs, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (r *Rules) merge(other *Rules) {
+	r.Rewrites = append(r.Rewrites, other.Rewrites...)
+	r.DropCommands = append(r.DropCommands, other.DropCommands...)
+	r.ExcludeFiles = append(r.ExcludeFiles, other.ExcludeFiles...)
+}
+
+func (r *Rules) compile() error {
+	r.rewrites = nil
+	for _, rw := range r.Rewrites {
+		re, err := regexp.Compile(rw.Pattern)
+		if err != nil {
+			return err
+		}
+		r.rewrites = append(r.rewrites, re)
+	}
+
+	r.dropCommands = nil
+	for _, pattern := range r.DropCommands {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return err
+		}
+		r.dropCommands = append(r.dropCommands, re)
+	}
+
+	r.excludeFiles = nil
+	for _, pattern := range r.ExcludeFiles {
+		re, err := globToRegexp(pattern)
+		if err != nil {
+			return err
+		}
+		r.excludeFiles = append(r.excludeFiles, re)
+	}
+	return nil
+}
+
+// globToRegexp converts a glob pattern to a regular expression.
+// '**' matches any number of directories, '*' and '?' don't match a path separator.
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	if rest, ok := strings.CutPrefix(pattern, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		pattern = filepath.Join(home, rest)
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !strings.Contains(pattern, "/") {
+		// match the file name in any directory
+		b.WriteString("(.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				b.WriteString(".*")
+				i++
+				continue
+			}
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// IsEmpty reports whether there are no rules to apply.
+func (r *Rules) IsEmpty() bool {
+	return r == nil || (len(r.rewrites) == 0 && len(r.dropCommands) == 0 && len(r.excludeFiles) == 0)
+}
+
+// AllowsFile reports whether the contents of the file at path may be recorded.
+// Relative paths are resolved against the current directory.
+func (r *Rules) AllowsFile(path string) bool {
+	if r == nil {
+		return true
+	}
+
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	for _, re := range r.excludeFiles {
+		if re.MatchString(path) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Rules) dropsCommand(cmd string) bool {
+	for _, re := range r.dropCommands {
+		if re.MatchString(cmd) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rules) rewrite(text string) string {
+	for i, re := range r.rewrites {
+		text = re.ReplaceAllString(text, r.Rewrites[i].Placeholder)
+	}
+	return text
+}
+
+// Apply drops and rewrites cmds according to the rules.
+func (r *Rules) Apply(cmds []*server.RecordedCommand) []*server.RecordedCommand {
+	if r.IsEmpty() {
+		return cmds
+	}
+
+	kept := slice.Filter(cmds, func(cmd *server.RecordedCommand) bool {
+		if cmd.FileInfo != nil {
+			path := cmd.FileInfo.Path
+			if !filepath.IsAbs(path) && cmd.WorkingDir != "" {
+				path = filepath.Join(cmd.WorkingDir, path)
+			}
+			return r.AllowsFile(path)
+		}
+		if cmd.IsNote() {
+			return true
+		}
+		return !r.dropsCommand(cmd.Command)
+	})
+
+	for _, cmd := range kept {
+		cmd.Command = r.rewrite(cmd.Command)
+		cmd.Note = r.rewrite(cmd.Note)
+		cmd.SubSteps = slice.Map(cmd.SubSteps, r.rewrite)
+		cmd.Output = r.rewrite(cmd.Output)
+		if cmd.FileInfo == nil {
+			continue
+		}
+		if cmd.FileInfo.IsText() {
+			cmd.FileInfo.Content = []byte(r.rewrite(string(cmd.FileInfo.Content)))
+			continue
+		}
+		// directories are recorded as a tarball.
+		content, err := archive.RewriteText(cmd.FileInfo.Content, func(_, text string) string {
+			return r.rewrite(text)
+		})
+		if err == nil {
+			cmd.FileInfo.Content = content
+		}
+	}
+	return kept
+}
@@ -0,0 +1,83 @@
+package redact
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/getsavvyinc/savvy-cli/archive"
+	"github.com/getsavvyinc/savvy-cli/server"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGlobToRegexp(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{pattern: ".env", path: "/home/user/project/.env", match: true},
+		{pattern: ".env", path: "/home/user/project/.envrc", match: false},
+		{pattern: "*.pem", path: "/etc/ssl/server.pem", match: true},
+		{pattern: "/etc/*.conf", path: "/etc/nginx/nginx.conf", match: false},
+		{pattern: "/etc/**/*.conf", path: "/etc/nginx/nginx.conf", match: true},
+		{pattern: "/secrets/**", path: "/secrets/prod/db.yaml", match: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.pattern+" "+tc.path, func(t *testing.T) {
+			re, err := globToRegexp(tc.pattern)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.match, re.MatchString(tc.path))
+		})
+	}
+}
+
+func TestRulesApply(t *testing.T) {
+	rules := &Rules{
+		Rewrites:     []Rewrite{{Pattern: `--token=\S+`, Placeholder: "--token=<token>"}},
+		DropCommands: []string{`^vault login`},
+		ExcludeFiles: []string{".env"},
+	}
+	assert.NoError(t, rules.compile())
+
+	cmds := []*server.RecordedCommand{
+		{Command: "vault login -method=userpass"},
+		{Command: "deploy --token=abc123 --env=prod", Output: "deploying with --token=abc123"},
+		{Command: "savvy record file .env", WorkingDir: "/app", FileInfo: &server.FileInfo{Path: ".env", Content: []byte("KEY=value")}},
+		{Command: "savvy record file config.yaml", WorkingDir: "/app", FileInfo: &server.FileInfo{Path: "config.yaml", Content: []byte("args: --token=abc123")}},
+	}
+
+	got := rules.Apply(cmds)
+	assert.Len(t, got, 2)
+	assert.Equal(t, "deploy --token=<token> --env=prod", got[0].Command)
+	assert.Equal(t, "deploying with --token=<token>", got[0].Output)
+	assert.Equal(t, "args: --token=<token>", string(got[1].FileInfo.Content))
+}
+
+func TestRulesApplyToDirectory(t *testing.T) {
+	rules := &Rules{Rewrites: []Rewrite{{Pattern: `--token=\S+`, Placeholder: "--token=<token>"}}}
+	assert.NoError(t, rules.compile())
+
+	dir := filepath.Join(t.TempDir(), "scripts")
+	assert.NoError(t, os.MkdirAll(dir, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "deploy.sh"), []byte("deploy --token=abc123\n"), 0755))
+	content, err := archive.Dir(dir, nil)
+	assert.NoError(t, err)
+
+	got := rules.Apply([]*server.RecordedCommand{
+		{Command: "savvy record file scripts", FileInfo: &server.FileInfo{Path: dir, Content: content, Type: server.FileTypeDirectory}},
+	})
+	assert.Len(t, got, 1)
+
+	dest := t.TempDir()
+	assert.NoError(t, archive.Extract(got[0].FileInfo.Content, dest))
+	bs, err := os.ReadFile(filepath.Join(dest, "scripts", "deploy.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, "deploy --token=<token>\n", string(bs))
+}
+
+func TestInvalidRules(t *testing.T) {
+	rules := &Rules{DropCommands: []string{`(`}}
+	assert.Error(t, rules.compile())
+}
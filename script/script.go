@@ -0,0 +1,131 @@
+// Package script builds recorded commands from shell scripts and terminal session logs without spawning a shell.
+package script
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/getsavvyinc/savvy-cli/server"
+)
+
+// heredocRegex matches the start of a heredoc e.g <<EOF, <<-'EOF' or << "END" but not a here-string (<<<).
+var heredocRegex = regexp.MustCompile(`(?:^|[^<])<<-?\s*["']?([A-Za-z_][A-Za-z0-9_]*)["']?`)
+
+var (
+	blockOpeners = map[string]bool{"if": true, "for": true, "while": true, "until": true, "case": true, "select": true, "{": true}
+	blockClosers = map[string]bool{"fi": true, "done": true, "esac": true, "}": true}
+	// commandSeparators are tokens after which a new command starts.
+	commandSeparators = map[string]bool{"&&": true, "||": true, "|": true, "then": true, "do": true, "else": true, "{": true, "(": true}
+)
+
+// ParseScript splits a shell script into the commands it runs.
+//
+// Comments and blank lines are skipped. Line continuations, heredocs and compound commands
+// (if, for, while, case, functions) are kept together as a single multi-line command.
+func ParseScript(r io.Reader) ([]*server.RecordedCommand, error) {
+	var commands []*server.RecordedCommand
+
+	var lines []string
+	var heredocs []string
+	depth := 0
+
+	flush := func() {
+		if len(lines) > 0 {
+			commands = append(commands, &server.RecordedCommand{Command: strings.Join(lines, "\n")})
+		}
+		lines = nil
+		heredocs = nil
+		depth = 0
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+
+		if len(heredocs) > 0 {
+			lines = append(lines, line)
+			if strings.TrimSpace(line) == heredocs[0] {
+				heredocs = heredocs[1:]
+			}
+			if len(heredocs) == 0 && depth <= 0 {
+				flush()
+			}
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if len(lines) == 0 && (trimmed == "" || strings.HasPrefix(trimmed, "#")) {
+			continue
+		}
+		if len(lines) == 0 {
+			line = trimmed
+		}
+		lines = append(lines, line)
+
+		for _, m := range heredocRegex.FindAllStringSubmatch(line, -1) {
+			heredocs = append(heredocs, m[1])
+		}
+		depth += blockDelta(line)
+
+		if len(heredocs) == 0 && depth <= 0 && !continues(line) {
+			flush()
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return commands, nil
+}
+
+// continues reports whether the command on line continues on the next line.
+func continues(line string) bool {
+	line = stripComment(line)
+	for _, suffix := range []string{`\`, "|", "&&", "||"} {
+		if strings.HasSuffix(line, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// blockDelta returns the number of compound commands opened minus the number closed on line.
+// Keywords only count in command position so that e.g `echo done` doesn't close a block.
+func blockDelta(line string) int {
+	delta := 0
+	commandStart := true
+	tokens := strings.Fields(stripComment(line))
+	for i, token := range tokens {
+		word := strings.TrimSuffix(token, ";")
+		switch {
+		case commandStart && blockOpeners[word]:
+			delta++
+		case commandStart && blockClosers[word]:
+			delta--
+		case word == "{" && i == len(tokens)-1:
+			// function definitions e.g `function deploy {`
+			delta++
+		}
+		commandStart = token != word || commandSeparators[word]
+		// function definitions e.g `deploy() {`
+		if strings.HasSuffix(word, "()") {
+			commandStart = true
+		}
+	}
+	return delta
+}
+
+// stripComment removes a trailing comment from line.
+func stripComment(line string) string {
+	if strings.HasPrefix(strings.TrimSpace(line), "#") {
+		return ""
+	}
+	if idx := strings.Index(line, " #"); idx >= 0 {
+		line = line[:idx]
+	}
+	return strings.TrimSpace(line)
+}
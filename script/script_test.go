@@ -0,0 +1,110 @@
+package script_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/getsavvyinc/savvy-cli/script"
+	"github.com/getsavvyinc/savvy-cli/server"
+	"github.com/stretchr/testify/assert"
+)
+
+func commandsOf(cmds []*server.RecordedCommand) []string {
+	var result []string
+	for _, cmd := range cmds {
+		result = append(result, cmd.Command)
+	}
+	return result
+}
+
+func TestParseScript(t *testing.T) {
+	testCases := []struct {
+		name     string
+		script   string
+		expected []string
+	}{
+		{
+			name: "simple commands",
+			script: `#!/bin/bash
+# deploy the api
+set -euo pipefail
+
+kubectl apply -f deploy.yaml
+kubectl rollout status deploy/api # wait for the rollout
+`,
+			expected: []string{"set -euo pipefail", "kubectl apply -f deploy.yaml", "kubectl rollout status deploy/api # wait for the rollout"},
+		},
+		{
+			name: "line continuation",
+			script: `docker run \
+  --rm \
+  alpine echo hi
+echo done`,
+			expected: []string{"docker run \\\n  --rm \\\n  alpine echo hi", "echo done"},
+		},
+		{
+			name: "heredoc",
+			script: `cat <<'EOF' > config.yaml
+if: true
+done: false
+EOF
+echo ok`,
+			expected: []string{"cat <<'EOF' > config.yaml\nif: true\ndone: false\nEOF", "echo ok"},
+		},
+		{
+			name: "compound commands",
+			script: `for pod in $(kubectl get pods -o name); do
+  kubectl delete "$pod"
+done
+if [ -f .env ]; then source .env; fi
+deploy() {
+  echo deploying
+}`,
+			expected: []string{
+				"for pod in $(kubectl get pods -o name); do\n  kubectl delete \"$pod\"\ndone",
+				"if [ -f .env ]; then source .env; fi",
+				"deploy() {\n  echo deploying\n}",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmds, err := script.ParseScript(strings.NewReader(tc.script))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, commandsOf(cmds))
+		})
+	}
+}
+
+func TestParseTypescript(t *testing.T) {
+	t.Run("script", func(t *testing.T) {
+		typescript := "Script started on 2024-05-01 10:00:00+00:00 [TERM=\"xterm\"]\r\n" +
+			"\x1b[32muser@host\x1b[0m:~/src$ kubectl get pods\r\n" +
+			"NAME    READY   STATUS\r\n" +
+			"api-1   1/1     Running\r\n" +
+			"user@host:~/src$ \r\n" +
+			"[user@host src]# echo 100% done\r\n" +
+			"100% done\r\n" +
+			"user@host:~/src$ exit\r\n" +
+			"Script done on 2024-05-01 10:01:00+00:00 [COMMAND_EXIT_CODE=\"0\"]\r\n"
+
+		cmds, err := script.ParseTypescript(strings.NewReader(typescript), nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"kubectl get pods", "echo 100% done", "exit"}, commandsOf(cmds))
+		assert.Equal(t, "NAME    READY   STATUS\napi-1   1/1     Running", cmds[0].Output)
+		assert.Equal(t, "100% done", cmds[1].Output)
+	})
+
+	t.Run("asciinema", func(t *testing.T) {
+		cast := `{"version": 2, "width": 80, "height": 24}
+[0.1, "o", "$ "]
+[0.5, "i", "echo hi\r"]
+[0.6, "o", "echo hi\r\nhi\r\n$ "]
+`
+		cmds, err := script.ParseTypescript(strings.NewReader(cast), nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"echo hi"}, commandsOf(cmds))
+		assert.Equal(t, "hi", cmds[0].Output)
+	})
+}
@@ -0,0 +1,117 @@
+package script
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/getsavvyinc/savvy-cli/server"
+)
+
+// DefaultPromptRegex matches common shell prompts at the start of a line, e.g:
+//
+//	$ ls
+//	user@host:~/src$ ls
+//	[user@host src]# ls
+//	(venv) host% ls
+//	❯ ls
+//
+// The command follows the prompt. A '%' prompt must not follow a digit so that e.g progress output isn't mistaken for a prompt.
+var DefaultPromptRegex = regexp.MustCompile(`^(?:\([^()]*\)\s+)?(?:(?:\[[^\]]*\]|[^\s$#%❯]*[\w~/)])?[$#❯]|(?:\[[^\]]*\]|[^\s$#%❯]*[A-Za-z_~/)])?%)(?:\s|$)`)
+
+// ParseTypescript extracts the commands, and their output, from a terminal session recorded with script(1) or asciinema.
+// Lines that match prompt start a new command, the lines that follow are its output.
+func ParseTypescript(r io.Reader, prompt *regexp.Regexp) ([]*server.RecordedCommand, error) {
+	if prompt == nil {
+		prompt = DefaultPromptRegex
+	}
+
+	bs, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, ok := asciinemaOutput(bs)
+	if !ok {
+		raw = stripScriptHeader(string(bs))
+	}
+
+	var commands []*server.RecordedCommand
+	var current *server.RecordedCommand
+	var output []string
+
+	finish := func() {
+		if current == nil {
+			return
+		}
+		current.Output = server.TruncateOutput(strings.TrimSpace(strings.Join(output, "\n")))
+		commands = append(commands, current)
+		current = nil
+		output = nil
+	}
+
+	for _, line := range strings.Split(server.CleanOutput(raw), "\n") {
+		if loc := prompt.FindStringIndex(line); loc != nil {
+			finish()
+			if cmd := strings.TrimSpace(line[loc[1]:]); cmd != "" {
+				current = &server.RecordedCommand{Command: cmd}
+			}
+			continue
+		}
+
+		if current != nil {
+			output = append(output, line)
+		}
+	}
+	finish()
+	return commands, nil
+}
+
+// stripScriptHeader removes the lines script(1) adds at the start and end of a typescript.
+func stripScriptHeader(typescript string) string {
+	lines := strings.Split(typescript, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if strings.HasPrefix(line, "Script started on") || strings.HasPrefix(line, "Script done on") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n")
+}
+
+// asciinemaOutput returns the terminal output recorded in an asciinema (v2 or later) cast file.
+// It returns false if bs isn't a cast file.
+func asciinemaOutput(bs []byte) (string, bool) {
+	scanner := bufio.NewScanner(bytes.NewReader(bs))
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+
+	if !scanner.Scan() {
+		return "", false
+	}
+
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil || header.Version < 2 {
+		return "", false
+	}
+
+	var out strings.Builder
+	for scanner.Scan() {
+		// events are [time, type, data]
+		var event []any
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil || len(event) != 3 {
+			continue
+		}
+		if eventType, _ := event[1].(string); eventType != "o" {
+			continue
+		}
+		data, _ := event[2].(string)
+		out.WriteString(data)
+	}
+	return out.String(), true
+}
@@ -3,10 +3,10 @@ package server
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net"
 	"os"
+	"time"
 
 	"github.com/getsavvyinc/savvy-cli/idgen"
 )
@@ -14,6 +14,18 @@ import (
 type Client interface {
 	// SendFileInfo tells the server to read the file at the given path
 	SendFileInfo(filePath string) error
+	// SendPause tells the server to stop recording commands until SendResume is called.
+	SendPause() error
+	// SendResume tells the server to resume recording commands.
+	SendResume() error
+	// SendUndo tells the server to remove the last n recorded steps and returns how many were removed.
+	SendUndo(n int) (int, error)
+	// SendNote tells the server to add a note step after the steps recorded so far.
+	SendNote(note string) error
+	// Status returns the steps recorded so far.
+	Status() (*Status, error)
+	// SendExcludedSteps tells the server to leave the given steps out of the export. All other steps are included.
+	SendExcludedSteps(stepIDs []string) error
 	ShutdownSender
 }
 
@@ -22,9 +34,10 @@ type ShutdownSender interface {
 	SendShutdown() error
 }
 
+// NewDefaultClient returns a client for the recording session of the current shell.
 func NewDefaultClient(ctx context.Context) (Client, error) {
 	return &client{
-		socketPath: DefaultSocketPath,
+		socketPath: SocketPathFromEnv(),
 	}, nil
 }
 
@@ -41,62 +54,118 @@ type client struct {
 var _ Client = &client{}
 
 func (c *client) SendShutdown() error {
-	conn, err := net.Dial("unix", c.socketPath)
-	if err != nil {
-		return err
-	}
-	defer conn.Close()
-
-	data := RecordedData{
+	return c.send(RecordedData{
 		Command: shutdownCommand,
+	})
+}
+
+func (c *client) SendPause() error {
+	return c.send(RecordedData{
+		Command: pauseCommand,
+	})
+}
+
+func (c *client) SendResume() error {
+	return c.send(RecordedData{
+		Command: resumeCommand,
+	})
+}
+
+// undoResponse is the server's response to an undo.
+type undoResponse struct {
+	Removed int `json:"removed"`
+}
+
+func (c *client) SendUndo(n int) (int, error) {
+	var resp undoResponse
+	if err := c.request(RecordedData{Command: undoCommand, UndoCount: n}, &resp); err != nil {
+		return 0, fmt.Errorf("failed to undo: %w", err)
 	}
+	return resp.Removed, nil
+}
 
-	return json.NewEncoder(conn).Encode(data)
+func (c *client) SendNote(note string) error {
+	wd, _ := os.Getwd()
+	return c.send(RecordedData{
+		Command:    noteCommand,
+		StepID:     idgen.New(idgen.NotePrefix),
+		Note:       note,
+		WorkingDir: wd,
+		StartedAt:  time.Now(),
+		Terminal:   os.Getenv(TerminalEnv),
+	})
 }
 
-func (c *client) SendFileInfo(filePath string) error {
+func (c *client) Status() (*Status, error) {
+	var status Status
+	if err := c.request(RecordedData{Command: statusCommand}, &status); err != nil {
+		return nil, fmt.Errorf("failed to read recording status: %w", err)
+	}
+	return &status, nil
+}
+
+// request sends data to the server and decodes its response into v.
+func (c *client) request(data RecordedData, v any) error {
 	conn, err := net.Dial("unix", c.socketPath)
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
 
-	if err := checkFile(filePath); err != nil {
+	if err := json.NewEncoder(conn).Encode(data); err != nil {
 		return err
 	}
 
-	data := RecordedData{
-		Command:  fmt.Sprintf("savvy record file %s", filePath),
-		Filepath: filePath,
-		StepID:   idgen.New(idgen.FilePrefix),
+	// The server reads the request until EOF before it responds.
+	if uc, ok := conn.(*net.UnixConn); ok {
+		if err := uc.CloseWrite(); err != nil {
+			return err
+		}
 	}
+	return json.NewDecoder(conn).Decode(v)
+}
 
-	if err := json.NewEncoder(conn).Encode(data); err != nil {
-		return err
-	}
-	return nil
+func (c *client) SendExcludedSteps(stepIDs []string) error {
+	return c.send(RecordedData{
+		Command:       excludeCommand,
+		ExcludedSteps: stepIDs,
+	})
 }
 
-func checkFile(filePath string) error {
-	if len(filePath) == 0 {
-		return nil
+func (c *client) send(data RecordedData) error {
+	conn, err := net.Dial("unix", c.socketPath)
+	if err != nil {
+		return err
 	}
+	defer conn.Close()
 
-	fi, err := os.Stat(filePath)
+	return json.NewEncoder(conn).Encode(data)
+}
+
+func (c *client) SendFileInfo(filePath string) error {
+	conn, err := net.Dial("unix", c.socketPath)
 	if err != nil {
 		return err
 	}
+	defer conn.Close()
 
-	if fi.IsDir() {
-		return errors.New("file path provided is a directory")
+	if err := CheckFile(filePath); err != nil {
+		return err
 	}
 
-	if fi.Size() == 0 {
-		return errors.New("file provided is empty")
+	wd, _ := os.Getwd()
+
+	data := RecordedData{
+		Command:    fmt.Sprintf("savvy record file %s", filePath),
+		Filepath:   filePath,
+		StepID:     idgen.New(idgen.FilePrefix),
+		WorkingDir: wd,
+		StartedAt:  time.Now(),
+		Terminal:   os.Getenv(TerminalEnv),
 	}
 
-	if fi.Size() > 25*1024 {
-		return errors.New("file provided is too large: max size is 25KB")
+	if err := json.NewEncoder(conn).Encode(data); err != nil {
+		return err
 	}
 	return nil
 }
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+
+	"github.com/getsavvyinc/savvy-cli/archive"
+	"github.com/getsavvyinc/savvy-cli/diff"
+)
+
+// FileType is the kind of content recorded by savvy record file.
+type FileType string
+
+const (
+	// FileTypeFile is the full content of a file. It is the default.
+	FileTypeFile FileType = ""
+	// FileTypeDirectory is a gzipped tarball of a directory.
+	FileTypeDirectory FileType = "directory"
+	// FileTypePatch is a unified diff against the content of the file when it was first recorded in the session.
+	FileTypePatch FileType = "patch"
+)
+
+const (
+	maxFileSize = 25 * 1024
+	// binarySniffLen is the number of bytes inspected to decide if a file is binary.
+	binarySniffLen = 8000
+)
+
+var (
+	ErrEmptyFile  = errors.New("file provided is empty")
+	ErrFileTooBig = fmt.Errorf("file provided is too large: max size is %dKB", maxFileSize/1024)
+	ErrBinaryFile = errors.New("file provided is a binary file: only text files can be recorded")
+)
+
+// CheckFile returns an error if the file or directory at filePath can't be recorded.
+func CheckFile(filePath string) error {
+	if len(filePath) == 0 {
+		return nil
+	}
+
+	fi, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+
+	if fi.IsDir() {
+		return checkDir(filePath)
+	}
+
+	if fi.Size() == 0 {
+		return ErrEmptyFile
+	}
+
+	if fi.Size() > maxFileSize {
+		return ErrFileTooBig
+	}
+
+	bs, err := readHead(filePath, binarySniffLen)
+	if err != nil {
+		return err
+	}
+	if isBinary(bs) {
+		return ErrBinaryFile
+	}
+	return nil
+}
+
+// checkDir returns an error if the directory is too large to be recorded.
+// Directories that aren't part of the recording e.g .git aren't counted.
+func checkDir(dir string) error {
+	var size int64
+	var files int
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if archive.IsSkippedDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		files++
+		if files > archive.MaxFiles {
+			return archive.ErrTooMany
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		if size > archive.MaxSize {
+			return archive.ErrTooLarge
+		}
+		return nil
+	})
+}
+
+func readHead(filePath string, n int64) ([]byte, error) {
+	fd, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	return io.ReadAll(io.LimitReader(fd, n))
+}
+
+// isBinary reports whether bs looks like the start of a binary file.
+func isBinary(bs []byte) bool {
+	if bytes.IndexByte(bs, 0) != -1 {
+		return true
+	}
+	// The sniffed bytes may end in the middle of a multi-byte character.
+	for i := 0; i < utf8.UTFMax && len(bs) > 0; i++ {
+		if utf8.Valid(bs) {
+			return false
+		}
+		bs = bs[:len(bs)-1]
+	}
+	return !utf8.Valid(bs)
+}
+
+// fileContent returns the content to record for the file at filePath.
+// Directories are recorded as a tarball and files that changed since they were first recorded in the session are recorded as a diff.
+// name is the path of the file in the headers of the diff, relative to the directory savvy record file ran in.
+// The caller must hold s.mu.
+func (s *UnixSocketServer) fileContent(filePath, name string) ([]byte, fs.FileMode, FileType, error) {
+	fi, err := os.Stat(filePath)
+	if err != nil {
+		return nil, 0, FileTypeFile, err
+	}
+
+	if fi.IsDir() {
+		bs, err := archive.Dir(filePath, s.allowFile)
+		if err != nil {
+			return nil, 0, FileTypeFile, err
+		}
+		return bs, fi.Mode(), FileTypeDirectory, nil
+	}
+
+	bs, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, 0, FileTypeFile, err
+	}
+
+	baseline, ok := s.baselines[filePath]
+	if !ok {
+		// Later changes to the file are recorded as a diff against this version.
+		s.setBaseline(filePath, bs)
+		return bs, fi.Mode(), FileTypeFile, nil
+	}
+
+	if bytes.Equal(baseline, bs) {
+		return bs, fi.Mode(), FileTypeFile, nil
+	}
+
+	name = filepath.ToSlash(name)
+	patch := diff.Unified("a/"+name, "b/"+name, string(baseline), string(bs))
+	return []byte(patch), fi.Mode(), FileTypePatch, nil
+}
+
+// setBaseline remembers the content of the file at path the first time it is seen.
+// The caller must hold s.mu.
+func (s *UnixSocketServer) setBaseline(path string, content []byte) {
+	if _, ok := s.baselines[path]; ok {
+		return
+	}
+	if s.baselines == nil {
+		s.baselines = make(map[string][]byte)
+	}
+	s.baselines[path] = content
+}
@@ -0,0 +1,185 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/getsavvyinc/savvy-cli/config"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultIgnoreFilePath holds the user's ignore policy for recorded commands.
+var DefaultIgnoreFilePath = filepath.Join(config.DefaultConfigDir, "ignore.yaml")
+
+// regexPatternPrefix marks an ignore pattern as a regular expression instead of a glob.
+const regexPatternPrefix = "re:"
+
+// DefaultIgnorePatterns are commands that are rarely useful in a runbook.
+// They only match simple commands: cd infra && terraform apply is a step worth keeping.
+var DefaultIgnorePatterns = []string{
+	"ls", "ls *", "ll", "ll *", "la", "la *",
+	"clear", "reset",
+	"cd", "cd *", "pwd",
+	"history", "history *",
+	"less *", "more *", "man *",
+	"exit",
+}
+
+// IgnorePolicy decides which commands are left out of a recording.
+//
+// Example:
+//
+//	patterns:
+//	  - 'git status'
+//	  - 'kubectl get *'
+//	  - 're:^vim? '
+//	default_patterns: false
+//	ignore_space: true
+//	collapse_duplicates: true
+type IgnorePolicy struct {
+	// Patterns are globs matched against the whole command, where '*' matches any text.
+	// Patterns prefixed with 're:' are regular expressions.
+	Patterns []string `yaml:"patterns"`
+	// DefaultPatterns adds the DefaultIgnorePatterns to Patterns. Defaults to true.
+	DefaultPatterns *bool `yaml:"default_patterns"`
+	// IgnoreSpace ignores commands that start with a space, like HIST_IGNORE_SPACE. Defaults to true.
+	IgnoreSpace *bool `yaml:"ignore_space"`
+	// CollapseDuplicates only keeps the last of consecutive identical commands, e.g retries. Defaults to true.
+	CollapseDuplicates *bool `yaml:"collapse_duplicates"`
+
+	patterns []*regexp.Regexp
+	// defaults are the compiled DefaultIgnorePatterns, if they are enabled.
+	defaults []*regexp.Regexp
+}
+
+// DefaultIgnorePolicy returns the policy used when the user hasn't configured one.
+func DefaultIgnorePolicy() *IgnorePolicy {
+	p := &IgnorePolicy{}
+	// The default patterns are valid.
+	_ = p.compile()
+	return p
+}
+
+// LoadIgnorePolicy loads the ignore policy from DefaultIgnoreFilePath.
+// It returns the DefaultIgnorePolicy if the file doesn't exist.
+func LoadIgnorePolicy() (*IgnorePolicy, error) {
+	bs, err := os.ReadFile(DefaultIgnoreFilePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return DefaultIgnorePolicy(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p := &IgnorePolicy{}
+	if err := yaml.Unmarshal(bs, p); err != nil {
+		return nil, fmt.Errorf("invalid ignore policy in %s: %w", DefaultIgnoreFilePath, err)
+	}
+	if err := p.compile(); err != nil {
+		return nil, fmt.Errorf("invalid ignore policy in %s: %w", DefaultIgnoreFilePath, err)
+	}
+	return p, nil
+}
+
+func (p *IgnorePolicy) compile() error {
+	var err error
+	if p.patterns, err = compileIgnorePatterns(p.Patterns); err != nil {
+		return err
+	}
+
+	p.defaults = nil
+	if enabled(p.DefaultPatterns) {
+		if p.defaults, err = compileIgnorePatterns(DefaultIgnorePatterns); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func compileIgnorePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := ignorePatternToRegexp(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// compoundOperators chain, pipe or redirect commands. Commands that contain them do more than their first command.
+var compoundOperators = []string{"&", "|", ";", ">", "<", "\n", "`", "$("}
+
+func isSimpleCommand(cmd string) bool {
+	for _, op := range compoundOperators {
+		if strings.Contains(cmd, op) {
+			return false
+		}
+	}
+	return true
+}
+
+func ignorePatternToRegexp(pattern string) (*regexp.Regexp, error) {
+	if expr, ok := strings.CutPrefix(pattern, regexPatternPrefix); ok {
+		return regexp.Compile(expr)
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	for i, part := range strings.Split(strings.TrimSpace(pattern), "*") {
+		if i > 0 {
+			b.WriteString(".*")
+		}
+		b.WriteString(regexp.QuoteMeta(part))
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// enabled returns the value of an optional setting that defaults to true.
+func enabled(b *bool) bool {
+	return b == nil || *b
+}
+
+// Ignores reports whether data must not be recorded.
+func (p *IgnorePolicy) Ignores(data RecordedData) bool {
+	if p == nil {
+		return false
+	}
+
+	if data.LeadingSpace && enabled(p.IgnoreSpace) {
+		return true
+	}
+
+	cmd := strings.TrimSpace(data.Command)
+	for _, re := range p.patterns {
+		if re.MatchString(cmd) {
+			return true
+		}
+	}
+	if !isSimpleCommand(cmd) {
+		return false
+	}
+	for _, re := range p.defaults {
+		if re.MatchString(cmd) {
+			return true
+		}
+	}
+	return false
+}
+
+// collapses reports whether data repeats the previous step, in the same terminal, and should replace it.
+func (p *IgnorePolicy) collapses(prev *RecordedData, data RecordedData) bool {
+	if p == nil || prev == nil || !enabled(p.CollapseDuplicates) {
+		return false
+	}
+	if prev.HasFileData() || prev.Terminal != data.Terminal {
+		return false
+	}
+	return strings.TrimSpace(prev.Command) == strings.TrimSpace(data.Command)
+}
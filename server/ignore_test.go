@@ -0,0 +1,70 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIgnorePolicy(t *testing.T) {
+	disabled := false
+	policy := &IgnorePolicy{
+		Patterns: []string{"kubectl get *", "re:^vim? "},
+	}
+	assert.NoError(t, policy.compile())
+
+	testCases := []struct {
+		name    string
+		data    RecordedData
+		ignored bool
+	}{
+		{name: "default pattern", data: RecordedData{Command: "ls -la"}, ignored: true},
+		{name: "default exact pattern", data: RecordedData{Command: "clear"}, ignored: true},
+		{name: "prefix is not a match", data: RecordedData{Command: "lsof -i :8080"}},
+		{name: "glob", data: RecordedData{Command: "kubectl get pods -n default"}, ignored: true},
+		{name: "regex", data: RecordedData{Command: "vi main.go"}, ignored: true},
+		{name: "leading space", data: RecordedData{Command: "export TOKEN=secret", LeadingSpace: true}, ignored: true},
+		{name: "not ignored", data: RecordedData{Command: "kubectl apply -f deploy.yaml"}},
+		{name: "and", data: RecordedData{Command: "cd infra && terraform apply"}},
+		{name: "or", data: RecordedData{Command: "cd build || mkdir build"}},
+		{name: "sequence", data: RecordedData{Command: "cd infra; terraform apply"}},
+		{name: "pipe", data: RecordedData{Command: "ls -la | xargs rm"}},
+		{name: "redirect", data: RecordedData{Command: "ls *.go > files.txt"}},
+		{name: "multiline", data: RecordedData{Command: "cd infra\nterraform apply"}},
+		{name: "compound command matching a user pattern", data: RecordedData{Command: "kubectl get pods | grep api"}, ignored: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.ignored, policy.Ignores(tc.data))
+		})
+	}
+
+	t.Run("without defaults", func(t *testing.T) {
+		policy := &IgnorePolicy{DefaultPatterns: &disabled, IgnoreSpace: &disabled}
+		assert.NoError(t, policy.compile())
+		assert.False(t, policy.Ignores(RecordedData{Command: "ls"}))
+		assert.False(t, policy.Ignores(RecordedData{Command: "echo hi", LeadingSpace: true}))
+	})
+
+	t.Run("invalid regex", func(t *testing.T) {
+		policy := &IgnorePolicy{Patterns: []string{"re:("}}
+		assert.Error(t, policy.compile())
+	})
+}
+
+func TestCollapseDuplicates(t *testing.T) {
+	srv := newTestServer(t)
+	srv.ignorePolicy = DefaultIgnorePolicy()
+
+	recordCommand(srv, "make build")
+	retry := recordCommand(srv, "make build")
+	recordCommand(srv, "ls")
+	recordCommand(srv, "make test")
+
+	cmds := srv.Commands()
+	assert.Len(t, cmds, 2)
+	assert.Equal(t, "make build", cmds[0].Command)
+	assert.Equal(t, "make test", cmds[1].Command)
+	assert.Contains(t, srv.lookupCommand, retry)
+}
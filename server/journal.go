@@ -0,0 +1,277 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getsavvyinc/savvy-cli/config"
+)
+
+// DefaultJournalDir is where recording sessions are journaled.
+var DefaultJournalDir = filepath.Join(config.DefaultConfigDir, "sessions")
+
+const journalExt = ".journal"
+
+// JournalPathEnv holds the journal of the recording session started by savvy record --here.
+const JournalPathEnv = "SAVVY_JOURNAL_PATH"
+
+// journalMaxAge is how long the journal of a session that was never exported is kept for savvy record recover.
+const journalMaxAge = 7 * 24 * time.Hour
+
+// Journal is an append-only log of the data accepted by a recording session.
+// It allows recovering a recording session if savvy or the terminal dies before the recording is exported.
+//
+// The journal holds the commands and output as recorded, before any secrets are redacted.
+// It is readable only by the user, removed once the session is exported and expires after journalMaxAge.
+type Journal struct {
+	path string
+
+	mu sync.Mutex
+	f  *os.File
+}
+
+// journalEntry is a single line in the journal.
+// The first entry in a journal only contains the session metadata.
+type journalEntry struct {
+	SocketPath   string        `json:"socket_path,omitempty"`
+	StartedAt    time.Time     `json:"started_at,omitempty"`
+	IgnoreErrors bool          `json:"ignore_errors,omitempty"`
+	Data         *RecordedData `json:"data,omitempty"`
+	// Output is the output of the step once it finishes.
+	Output string `json:"output,omitempty"`
+}
+
+// NewJournal creates a new journal for a recording session.
+// The journal holds unredacted data, so callers must Remove it once the session is exported.
+// Journals of earlier sessions that expired are removed.
+func NewJournal() (*Journal, error) {
+	if err := os.MkdirAll(DefaultJournalDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create journal dir: %w", err)
+	}
+	removeExpiredJournals()
+
+	name := time.Now().Format("20060102-150405") + journalExt
+	f, err := os.CreateTemp(DefaultJournalDir, "*-"+name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create journal: %w", err)
+	}
+	return &Journal{path: f.Name(), f: f}, nil
+}
+
+// OpenJournal opens an existing journal to append to it.
+func OpenJournal(path string) (*Journal, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	return &Journal{path: path, f: f}, nil
+}
+
+// Path returns the path of the journal file.
+func (j *Journal) Path() string {
+	return j.path
+}
+
+func (j *Journal) append(entry journalEntry) error {
+	if j == nil {
+		return nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.f == nil {
+		return errors.New("journal is closed")
+	}
+
+	bs, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if _, err := j.f.Write(append(bs, '\n')); err != nil {
+		return err
+	}
+	// The whole point of the journal is to survive crashes.
+	return j.f.Sync()
+}
+
+// Close closes the journal file. The journal can still be recovered after it is closed.
+func (j *Journal) Close() error {
+	if j == nil {
+		return nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.f == nil {
+		return nil
+	}
+	err := j.f.Close()
+	j.f = nil
+	return err
+}
+
+// Remove closes and deletes the journal.
+// It must be called once the recorded commands have been exported.
+func (j *Journal) Remove() error {
+	if j == nil {
+		return nil
+	}
+
+	if err := j.Close(); err != nil {
+		return err
+	}
+	return os.Remove(j.path)
+}
+
+// removeExpiredJournals removes the journals that weren't written to for journalMaxAge.
+func removeExpiredJournals() {
+	paths, err := filepath.Glob(filepath.Join(DefaultJournalDir, "*"+journalExt))
+	if err != nil {
+		return
+	}
+	for _, path := range paths {
+		if fi, err := os.Stat(path); err == nil && time.Since(fi.ModTime()) > journalMaxAge {
+			os.Remove(path)
+		}
+	}
+}
+
+// UnfinishedSession is a recording session that was never exported.
+type UnfinishedSession struct {
+	Journal   *Journal
+	StartedAt time.Time
+	Steps     int
+}
+
+// UnfinishedSessions returns the recording sessions that are no longer running but were never exported.
+// The most recent session is returned first.
+func UnfinishedSessions() ([]*UnfinishedSession, error) {
+	paths, err := filepath.Glob(filepath.Join(DefaultJournalDir, "*"+journalExt))
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []*UnfinishedSession
+	for _, path := range paths {
+		entries, err := readJournal(path)
+		if err != nil || len(entries) == 0 {
+			continue
+		}
+
+		header := entries[0]
+		if header.SocketPath != "" && isSessionAlive(header.SocketPath) {
+			continue
+		}
+
+		commands := replay(entries)
+		sessions = append(sessions, &UnfinishedSession{
+			Journal:   &Journal{path: path},
+			StartedAt: header.StartedAt,
+			Steps:     len(commands),
+		})
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].StartedAt.After(sessions[j].StartedAt)
+	})
+	return sessions, nil
+}
+
+// Commands returns the commands recorded in the unfinished session.
+func (us *UnfinishedSession) Commands() ([]*RecordedCommand, error) {
+	return us.Journal.Commands()
+}
+
+// Commands returns the commands recorded in the journal so far.
+func (j *Journal) Commands() ([]*RecordedCommand, error) {
+	entries, err := readJournal(j.path)
+	if err != nil {
+		return nil, err
+	}
+	return replay(entries), nil
+}
+
+func isSessionAlive(socketPath string) bool {
+	conn, err := net.DialTimeout("unix", socketPath, 100*time.Millisecond)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+func readJournal(path string) ([]journalEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []journalEntry
+	scanner := bufio.NewScanner(f)
+	// recorded files can be large
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var entry journalEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			// A crash may leave a partially written last line. Keep everything before it.
+			break
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// replay rebuilds the recorded commands from the journal entries.
+func replay(entries []journalEntry) []*RecordedCommand {
+	s := &UnixSocketServer{
+		logger:        defaultLogger,
+		lookupCommand: make(map[string]*RecordedData),
+		outputs:       make(map[string]*stepOutput),
+	}
+
+	if len(entries) > 0 {
+		s.ignoreErrors = entries[0].IgnoreErrors
+	}
+
+	for _, entry := range entries {
+		if entry.Data == nil {
+			continue
+		}
+		data := *entry.Data
+
+		switch {
+		case data.Command == undoCommand:
+			s.undo(data.UndoCount)
+		case data.Command == excludeCommand:
+			s.setExcluded(data.ExcludedSteps)
+		case data.IsStepFinished():
+			s.finishStep(data)
+			if entry.Output != "" {
+				output := &stepOutput{}
+				output.buf.WriteString(entry.Output)
+				s.outputs[data.StepID] = output
+			}
+		default:
+			s.record(&data)
+		}
+	}
+	return s.Commands()
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/getsavvyinc/savvy-cli/idgen"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournal(t *testing.T) {
+	DefaultJournalDir = t.TempDir()
+
+	journal, err := NewJournal()
+	assert.NoError(t, err)
+
+	socketPath := "/tmp/savvy-record-test-" + idgen.New("tst") + ".sock"
+	srv, err := NewUnixSocketServer(socketPath, WithJournal(journal))
+	assert.NoError(t, err)
+
+	first := recordCommand(srv, "echo one")
+	srv.Write([]byte("one\r\n"))
+	srv.finishStep(RecordedData{StepID: first, ExitCode: 2})
+	recordCommand(srv, "echo two")
+	recordCommand(srv, "echo three")
+	srv.undo(1)
+
+	t.Run("TestLiveSessionIsNotListed", func(t *testing.T) {
+		go srv.ListenAndServe()
+		sessions, err := UnfinishedSessions()
+		assert.NoError(t, err)
+		assert.Empty(t, sessions)
+	})
+
+	// simulate a crash: the server goes away without the journal being removed.
+	srv.Close()
+
+	sessions, err := UnfinishedSessions()
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, 2, sessions[0].Steps)
+
+	cmds, err := sessions[0].Commands()
+	assert.NoError(t, err)
+	assert.Len(t, cmds, 2)
+	assert.Equal(t, "echo one", cmds[0].Command)
+	assert.Equal(t, "one", cmds[0].Output)
+	assert.Equal(t, "echo two", cmds[1].Command)
+
+	t.Run("TestRemove", func(t *testing.T) {
+		assert.NoError(t, sessions[0].Journal.Remove())
+		_, err := os.Stat(journal.Path())
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestExpiredJournalsAreRemoved(t *testing.T) {
+	DefaultJournalDir = t.TempDir()
+
+	expired, err := NewJournal()
+	assert.NoError(t, err)
+	assert.NoError(t, expired.Close())
+	old := time.Now().Add(-journalMaxAge - time.Hour)
+	assert.NoError(t, os.Chtimes(expired.Path(), old, old))
+
+	recent, err := NewJournal()
+	assert.NoError(t, err)
+	assert.NoError(t, recent.Close())
+
+	current, err := NewJournal()
+	assert.NoError(t, err)
+	defer current.Remove()
+
+	fi, err := os.Stat(current.Path())
+	assert.NoError(t, err)
+	// journals hold unredacted data.
+	assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+
+	_, err = os.Stat(expired.Path())
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(recent.Path())
+	assert.NoError(t, err)
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// maxRawOutputSize caps the number of raw pty bytes buffered for a single step.
+	maxRawOutputSize = 64 * 1024
+	// MaxOutputSize caps the size of the cleaned up output attached to a recorded command.
+	MaxOutputSize = 4 * 1024
+)
+
+const truncatedOutputMarker = "\n... (output truncated)"
+
+// ansiRegex matches CSI, OSC and other two byte escape sequences emitted by terminals.
+var ansiRegex = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(\x07|\x1b\\)|\x1b[()][A-Za-z0-9]|\x1b[@-_]`)
+
+// stepOutput accumulates raw pty output for a single recorded step.
+type stepOutput struct {
+	buf       bytes.Buffer
+	truncated bool
+}
+
+func (o *stepOutput) Write(p []byte) {
+	remaining := maxRawOutputSize - o.buf.Len()
+	if remaining <= 0 {
+		o.truncated = true
+		return
+	}
+	if len(p) > remaining {
+		p = p[:runeBoundary(p, remaining)]
+		o.truncated = true
+	}
+	o.buf.Write(p)
+}
+
+func (o *stepOutput) String() string {
+	cleaned := CleanOutput(o.buf.String())
+	if o.truncated && len(cleaned) <= MaxOutputSize {
+		return cleaned + truncatedOutputMarker
+	}
+	return TruncateOutput(cleaned)
+}
+
+// TruncateOutput caps cleaned up output at MaxOutputSize.
+func TruncateOutput(output string) string {
+	return TruncateOutputTo(output, MaxOutputSize, truncatedOutputMarker)
+}
+
+// TruncateOutputTo caps output at max bytes without splitting a rune, and appends marker if the output was truncated.
+func TruncateOutputTo(output string, max int, marker string) string {
+	if len(output) <= max {
+		return output
+	}
+	return output[:runeBoundary(output, max)] + marker
+}
+
+// runeBoundary returns the largest index <= n at which s can be cut without splitting a UTF-8 encoded rune.
+func runeBoundary[T string | []byte](s T, n int) int {
+	if n >= len(s) {
+		return len(s)
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return n
+}
+
+// CleanOutput strips ANSI escape sequences and carriage returns from terminal output.
+func CleanOutput(raw string) string {
+	s := ansiRegex.ReplaceAllString(raw, "")
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+
+	// A bare carriage return moves the cursor to the start of the line, so only the text after it is visible.
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if idx := strings.LastIndex(line, "\r"); idx >= 0 {
+			lines[i] = line[idx+1:]
+		}
+	}
+
+	s = strings.Join(lines, "\n")
+	s = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' || r >= ' ' {
+			return r
+		}
+		return -1
+	}, s)
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCleanOutput(t *testing.T) {
+	testCases := []struct {
+		name     string
+		raw      string
+		expected string
+	}{
+		{
+			name:     "plain text",
+			raw:      "hello world\r\n",
+			expected: "hello world",
+		},
+		{
+			name:     "colors",
+			raw:      "\x1b[1;32mok\x1b[0m\r\n\x1b[31merror\x1b[0m",
+			expected: "ok\nerror",
+		},
+		{
+			name:     "osc title",
+			raw:      "\x1b]0;user@host: ~\x07done",
+			expected: "done",
+		},
+		{
+			name:     "carriage return overwrites line",
+			raw:      "progress 10%\rprogress 100%\r\n",
+			expected: "progress 100%",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, CleanOutput(tc.raw))
+		})
+	}
+}
+
+func TestStepOutputTruncation(t *testing.T) {
+	var o stepOutput
+	o.Write([]byte(strings.Repeat("a", MaxOutputSize+10)))
+
+	out := o.String()
+	assert.True(t, strings.HasSuffix(out, truncatedOutputMarker))
+	assert.Len(t, out, MaxOutputSize+len(truncatedOutputMarker))
+}
+
+func TestTruncateOutputOnRuneBoundary(t *testing.T) {
+	testCases := []struct {
+		name   string
+		output string
+	}{
+		{name: "2 byte runes", output: "a" + strings.Repeat("é", MaxOutputSize)},
+		{name: "3 byte runes", output: strings.Repeat("€", MaxOutputSize)},
+		{name: "4 byte runes", output: "ab" + strings.Repeat("🚀", MaxOutputSize)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			out := TruncateOutput(tc.output)
+			assert.True(t, utf8.ValidString(out))
+			assert.True(t, strings.HasSuffix(out, truncatedOutputMarker))
+			assert.LessOrEqual(t, len(out), MaxOutputSize+len(truncatedOutputMarker))
+			assert.Greater(t, len(out), MaxOutputSize-utf8.UTFMax+len(truncatedOutputMarker))
+
+			var o stepOutput
+			o.Write([]byte(tc.output))
+			assert.True(t, utf8.ValidString(o.String()))
+		})
+	}
+
+	t.Run("raw output", func(t *testing.T) {
+		var o stepOutput
+		o.Write([]byte("a" + strings.Repeat("€", maxRawOutputSize)))
+		assert.True(t, utf8.Valid(o.buf.Bytes()))
+		assert.True(t, o.truncated)
+	})
+}
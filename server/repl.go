@@ -0,0 +1,486 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// repl describes an interactive program whose input lines are recorded as sub-steps.
+type repl struct {
+	name string
+	// prompt matches the prompt the repl prints before each line of input.
+	prompt *regexp.Regexp
+	// keepEmptyLines is true if empty lines are meaningful e.g they end a block in python.
+	keepEmptyLines bool
+	// exitLines are the lines that leave the repl. They aren't recorded.
+	exitLines []string
+}
+
+var (
+	sqlExitLines    = []string{`\q`, "exit", "quit", `\quit`}
+	scriptExitLines = []string{"exit", "quit", "exit()", "quit()", ".exit"}
+)
+
+var repls = []*repl{
+	{name: "psql", prompt: regexp.MustCompile(`^.*?[=\-\(\*'"!^]?[#>] `), exitLines: sqlExitLines},
+	{name: "mysql", prompt: regexp.MustCompile(`^.*?(?:mysql|mariadb|MariaDB \[[^\]]*\]|\s*-)> `), exitLines: sqlExitLines},
+	{name: "mariadb", prompt: regexp.MustCompile(`^.*?(?:mysql|mariadb|MariaDB \[[^\]]*\]|\s*-)> `), exitLines: sqlExitLines},
+	{name: "sqlite3", prompt: regexp.MustCompile(`^(?:sqlite|\s*\.\.\.)> `), exitLines: []string{".quit", ".exit"}},
+	{name: "redis-cli", prompt: regexp.MustCompile(`^\S*> `), exitLines: sqlExitLines},
+	{name: "mongosh", prompt: regexp.MustCompile(`^.*?> `), exitLines: scriptExitLines},
+	{name: "python", prompt: regexp.MustCompile(`^(?:>>>|\.\.\.) ?`), keepEmptyLines: true, exitLines: scriptExitLines},
+	{name: "ipython", prompt: regexp.MustCompile(`^(?:In \[\d+\]:|\s*\.\.\.:) ?`), keepEmptyLines: true, exitLines: scriptExitLines},
+	{name: "node", prompt: regexp.MustCompile(`^(?:>|\.\.\.) ?`), exitLines: scriptExitLines},
+	{name: "irb", prompt: regexp.MustCompile(`^irb\([^)]*\):\d+:\d+[>*"'] ?`), exitLines: scriptExitLines},
+}
+
+var pythonRegex = regexp.MustCompile(`^python[0-9.]*$`)
+
+// detectREPL returns the repl started by command, or nil if command doesn't start a known repl.
+// Commands that run the repl with a wrapper e.g sudo -u postgres psql or in a container e.g docker exec -it db psql
+// are detected too. Non interactive invocations e.g psql -c are detected as well; they simply don't receive any input.
+func detectREPL(command string) *repl {
+	if strings.ContainsAny(command, "|<") {
+		// the repl reads its input from a pipe or file, not from the user.
+		return nil
+	}
+
+	name := commandName(strings.Fields(command))
+	if pythonRegex.MatchString(name) {
+		name = "python"
+	}
+	for _, r := range repls {
+		if r.name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+var envAssignmentRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+
+// wrappers run the command that follows their flags. The values are the flags that take a separate value.
+var wrappers = map[string][]string{
+	"sudo":    {"-u", "-g", "-h", "-p", "-C", "-D", "-U", "-r", "-t"},
+	"env":     {"-u", "-C"},
+	"nice":    {"-n"},
+	"time":    nil,
+	"exec":    nil,
+	"command": nil,
+	"nohup":   nil,
+}
+
+// containerExecFlags are the flags that take a separate value for the exec subcommand of container tools.
+var containerExecFlags = map[string][]string{
+	"docker":         {"-e", "--env", "--env-file", "-u", "--user", "-w", "--workdir", "--detach-keys"},
+	"podman":         {"-e", "--env", "--env-file", "-u", "--user", "-w", "--workdir", "--detach-keys"},
+	"docker-compose": {"-e", "--env", "-u", "--user", "-w", "--workdir", "--index"},
+	"kubectl":        {"-c", "--container", "-n", "--namespace", "--context", "--pod-running-timeout"},
+}
+
+// commandName returns the name of the program that the command made of fields runs.
+// Environment assignments, wrappers like sudo and container tools like docker exec are skipped.
+func commandName(fields []string) string {
+	for len(fields) > 0 {
+		name := filepath.Base(fields[0])
+		if envAssignmentRegex.MatchString(fields[0]) {
+			fields = fields[1:]
+			continue
+		}
+		if valueFlags, ok := wrappers[name]; ok {
+			fields = skipFlags(fields[1:], valueFlags)
+			continue
+		}
+
+		valueFlags, ok := containerExecFlags[name]
+		if !ok {
+			return name
+		}
+		args := fields[1:]
+		if len(args) > 0 && args[0] == "compose" && name != "kubectl" {
+			args = args[1:]
+			valueFlags = containerExecFlags["docker-compose"]
+		}
+		if len(args) == 0 || args[0] != "exec" {
+			return name
+		}
+		args = args[1:]
+		// kubectl exec pod -- psql
+		if i := slices.Index(args, "--"); i >= 0 {
+			fields = args[i+1:]
+			continue
+		}
+		// skip the container.
+		args = skipFlags(args, valueFlags)
+		if len(args) == 0 {
+			return ""
+		}
+		fields = args[1:]
+	}
+	return ""
+}
+
+// skipFlags returns args without the flags they start with. valueFlags take the argument that follows them.
+func skipFlags(args []string, valueFlags []string) []string {
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		if args[0] == "--" {
+			return args[1:]
+		}
+		if slices.Contains(valueFlags, args[0]) {
+			args = args[1:]
+		}
+		if len(args) > 0 {
+			args = args[1:]
+		}
+	}
+	return args
+}
+
+// replSession records the lines the user enters in a repl started by a recorded step.
+type replSession struct {
+	stepID string
+	repl   *repl
+	editor lineEditor
+	// echo holds the output since the last line was entered or resolved.
+	echo headBuffer
+	// pending holds the lines that were entered but not yet checked against the output of the repl.
+	pending []pendingLine
+	lines   []string
+}
+
+// pendingLine is an entered line waiting for the repl to echo it.
+type pendingLine struct {
+	editedLine
+	// displayed is the line that the repl displayed when the user pressed enter.
+	displayed string
+}
+
+func newREPLSession(stepID string, r *repl) *replSession {
+	return &replSession{stepID: stepID, repl: r}
+}
+
+// input feeds the keys the user typed to the session.
+//
+// Input is fed before it is written to the pty, so lines entered earlier are resolved first:
+// by the time the user types again, the repl has echoed them.
+func (rs *replSession) input(p []byte) {
+	rs.resolve()
+	for _, line := range rs.editor.feed(p) {
+		rs.pending = append(rs.pending, pendingLine{editedLine: line, displayed: lastLine(rs.echo.String())})
+		rs.echo.Reset()
+	}
+}
+
+func (rs *replSession) output(p []byte) {
+	rs.echo.Write(p)
+}
+
+// finish resolves the remaining lines and returns all recorded lines.
+func (rs *replSession) finish() []string {
+	rs.resolve()
+	return rs.lines
+}
+
+// resolve records the pending lines.
+// Lines are only recorded if the repl echoed them so that passwords typed at a hidden prompt are never recorded.
+func (rs *replSession) resolve() {
+	if len(rs.pending) == 0 {
+		return
+	}
+	echoed := ansiRegex.ReplaceAllString(rs.echo.String(), "")
+	// keep the prompt the repl displays for the next line.
+	rs.echo.KeepLastLine()
+
+	for _, line := range rs.pending {
+		text := line.text
+		if line.uncertain {
+			// history or completion changed the line in ways that can't be followed from the keys alone,
+			// so fall back to the line the repl displayed.
+			loc := rs.repl.prompt.FindStringIndex(line.displayed)
+			if loc == nil {
+				continue
+			}
+			text = line.displayed[loc[1]:]
+		} else if !strings.Contains(line.displayed, text) && !strings.Contains(echoed, text) {
+			continue
+		}
+		rs.record(text)
+	}
+	rs.pending = nil
+}
+
+func (rs *replSession) record(text string) {
+	text = strings.TrimRight(text, " \t")
+	if strings.TrimSpace(text) == "" && !rs.repl.keepEmptyLines {
+		return
+	}
+	for _, exit := range rs.repl.exitLines {
+		if strings.TrimSpace(text) == exit {
+			return
+		}
+	}
+	rs.lines = append(rs.lines, text)
+}
+
+// lastLine returns the last line of terminal output as it is displayed.
+func lastLine(raw string) string {
+	cleaned := ansiRegex.ReplaceAllString(raw, "")
+	cleaned = strings.TrimRight(cleaned, "\r\n")
+	if idx := strings.LastIndex(cleaned, "\n"); idx >= 0 {
+		cleaned = cleaned[idx+1:]
+	}
+	if idx := strings.LastIndex(cleaned, "\r"); idx >= 0 {
+		cleaned = cleaned[idx+1:]
+	}
+
+	// apply backspaces that move the cursor back over the line.
+	var line []rune
+	for _, r := range cleaned {
+		switch {
+		case r == '\b':
+			if len(line) > 0 {
+				line = line[:len(line)-1]
+			}
+		case r >= ' ' || r == '\t':
+			line = append(line, r)
+		}
+	}
+	return string(line)
+}
+
+// maxEchoSize caps the output kept to check that entered lines were echoed.
+const maxEchoSize = 16 * 1024
+
+// headBuffer keeps the first maxEchoSize bytes written to it.
+type headBuffer struct {
+	buf []byte
+}
+
+func (b *headBuffer) Write(p []byte) {
+	if n := maxEchoSize - len(b.buf); n < len(p) {
+		p = p[:max(n, 0)]
+	}
+	b.buf = append(b.buf, p...)
+}
+
+func (b *headBuffer) String() string {
+	return string(b.buf)
+}
+
+func (b *headBuffer) Reset() {
+	b.buf = b.buf[:0]
+}
+
+// KeepLastLine drops everything but the line that is being written.
+func (b *headBuffer) KeepLastLine() {
+	if idx := bytes.LastIndexByte(b.buf, '\n'); idx >= 0 {
+		b.buf = append(b.buf[:0], b.buf[idx+1:]...)
+	}
+}
+
+// editedLine is a line of input submitted with enter.
+type editedLine struct {
+	text string
+	// uncertain is true if the line was changed with keys whose effect depends on the repl e.g history or tab completion.
+	uncertain bool
+}
+
+// lineEditor follows the keys typed into a readline-like prompt to reconstruct the submitted lines.
+type lineEditor struct {
+	line      []rune
+	cursor    int
+	uncertain bool
+	// pending holds an incomplete escape sequence or utf8 character.
+	pending []byte
+	pasting bool
+}
+
+func (e *lineEditor) feed(p []byte) []editedLine {
+	var submitted []editedLine
+
+	data := append(e.pending, p...)
+	e.pending = nil
+
+	for i := 0; i < len(data); {
+		b := data[i]
+		switch {
+		case b == '\r' || b == '\n':
+			submitted = append(submitted, editedLine{text: string(e.line), uncertain: e.uncertain})
+			e.reset()
+			// \r\n is a single enter.
+			if b == '\r' && i+1 < len(data) && data[i+1] == '\n' {
+				i++
+			}
+			i++
+		case b == 0x1b:
+			n, complete := e.escape(data[i:])
+			if !complete {
+				e.pending = append([]byte(nil), data[i:]...)
+				return submitted
+			}
+			i += n
+		case b == 0x7f || b == 0x08: // backspace
+			if e.cursor > 0 {
+				e.line = append(e.line[:e.cursor-1], e.line[e.cursor:]...)
+				e.cursor--
+			}
+			i++
+		case b == 0x03: // ctrl-c discards the line
+			e.reset()
+			i++
+		case b == 0x15: // ctrl-u
+			e.line = e.line[e.cursor:]
+			e.cursor = 0
+			i++
+		case b == 0x0b: // ctrl-k
+			e.line = e.line[:e.cursor]
+			i++
+		case b == 0x17: // ctrl-w
+			start := e.cursor
+			for start > 0 && e.line[start-1] == ' ' {
+				start--
+			}
+			for start > 0 && e.line[start-1] != ' ' {
+				start--
+			}
+			e.line = append(e.line[:start], e.line[e.cursor:]...)
+			e.cursor = start
+			i++
+		case b == 0x01: // ctrl-a
+			e.cursor = 0
+			i++
+		case b == 0x05: // ctrl-e
+			e.cursor = len(e.line)
+			i++
+		case b == 0x02: // ctrl-b
+			e.left()
+			i++
+		case b == 0x06: // ctrl-f
+			e.right()
+			i++
+		case b == '\t' && !e.pasting, b == 0x10, b == 0x0e, b == 0x12, b == 0x19:
+			// tab completion, ctrl-p/ctrl-n history, ctrl-r search and ctrl-y yank.
+			e.uncertain = true
+			i++
+		case b < ' ' && b != '\t':
+			i++
+		default:
+			r, size := utf8.DecodeRune(data[i:])
+			if r == utf8.RuneError && !utf8.FullRune(data[i:]) {
+				e.pending = append([]byte(nil), data[i:]...)
+				return submitted
+			}
+			e.insert(r)
+			i += size
+		}
+	}
+	return submitted
+}
+
+// escape handles the escape sequence at the start of data.
+// It returns the length of the sequence and false if the sequence is incomplete.
+func (e *lineEditor) escape(data []byte) (int, bool) {
+	if len(data) < 2 {
+		return 0, false
+	}
+
+	switch data[1] {
+	case '[':
+		// CSI: ESC [ params final
+		end := 2
+		for end < len(data) && (data[end] < 0x40 || data[end] > 0x7e) {
+			end++
+		}
+		if end == len(data) {
+			return 0, false
+		}
+		e.csi(string(data[2:end]), data[end])
+		return end + 1, true
+	case 'O':
+		// SS3: ESC O final e.g arrow keys in application mode.
+		if len(data) < 3 {
+			return 0, false
+		}
+		e.csi("", data[2])
+		return 3, true
+	default:
+		// alt-b, alt-f, alt-d etc edit words.
+		e.uncertain = true
+		return 2, true
+	}
+}
+
+func (e *lineEditor) csi(params string, final byte) {
+	switch {
+	case final == 'C':
+		e.right()
+	case final == 'D':
+		e.left()
+	case final == 'H' || (final == '~' && (params == "1" || params == "7")):
+		e.cursor = 0
+	case final == 'F' || (final == '~' && (params == "4" || params == "8")):
+		e.cursor = len(e.line)
+	case final == '~' && params == "3": // delete
+		if e.cursor < len(e.line) {
+			e.line = append(e.line[:e.cursor], e.line[e.cursor+1:]...)
+		}
+	case final == '~' && params == "200":
+		e.pasting = true
+	case final == '~' && params == "201":
+		e.pasting = false
+	default:
+		// up/down arrows, page up/down etc change the line in ways that depend on the repl.
+		e.uncertain = true
+	}
+}
+
+func (e *lineEditor) insert(r rune) {
+	e.line = append(e.line[:e.cursor], append([]rune{r}, e.line[e.cursor:]...)...)
+	e.cursor++
+}
+
+func (e *lineEditor) left() {
+	if e.cursor > 0 {
+		e.cursor--
+	}
+}
+
+func (e *lineEditor) right() {
+	if e.cursor < len(e.line) {
+		e.cursor++
+	}
+}
+
+func (e *lineEditor) reset() {
+	e.line = nil
+	e.cursor = 0
+	e.uncertain = false
+}
+
+// InputWriter returns a writer for the keys the user types in the recorded shell.
+// It is used to record the lines entered in repls like psql or python that a recorded command starts.
+//
+// The writer never returns an error so that it is safe to use with io.MultiWriter.
+func (s *UnixSocketServer) InputWriter() io.Writer {
+	return inputWriter{s: s}
+}
+
+type inputWriter struct {
+	s *UnixSocketServer
+}
+
+func (w inputWriter) Write(p []byte) (int, error) {
+	s := w.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.repl != nil && s.repl.stepID == s.currentStepID {
+		s.repl.input(p)
+	}
+	return len(p), nil
+}
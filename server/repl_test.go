@@ -0,0 +1,196 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/getsavvyinc/savvy-cli/idgen"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectREPL(t *testing.T) {
+	testCases := []struct {
+		command  string
+		expected string
+	}{
+		{command: "psql -h localhost -U postgres app", expected: "psql"},
+		{command: "docker exec -it db /usr/bin/psql -U postgres", expected: "psql"},
+		{command: "python3.11", expected: "python"},
+		{command: "redis-cli -h cache", expected: "redis-cli"},
+		{command: "psql -f schema.sql < /dev/null", expected: ""},
+		{command: "echo 'select 1' | psql", expected: ""},
+		{command: "ls -la", expected: ""},
+		{command: "PGPASSWORD=secret psql -h db", expected: "psql"},
+		{command: "sudo -u postgres psql", expected: "psql"},
+		{command: "env TERM=dumb mysql -u root", expected: "mysql"},
+		{command: "docker exec -it -e PGUSER=app -w /tmp db psql", expected: "psql"},
+		{command: "docker compose exec db psql", expected: "psql"},
+		{command: "kubectl exec -it -n api api-0 -- python3", expected: "python"},
+		{command: "ls python", expected: ""},
+		{command: "git log psql", expected: ""},
+		{command: "echo node", expected: ""},
+		{command: "docker ps psql", expected: ""},
+		{command: "sudo systemctl restart redis-cli", expected: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.command, func(t *testing.T) {
+			r := detectREPL(tc.command)
+			if tc.expected == "" {
+				assert.Nil(t, r)
+				return
+			}
+			if assert.NotNil(t, r) {
+				assert.Equal(t, tc.expected, r.name)
+			}
+		})
+	}
+}
+
+func TestLineEditor(t *testing.T) {
+	testCases := []struct {
+		name      string
+		input     []string
+		expected  []editedLine
+		unchanged bool
+	}{
+		{
+			name:     "typed line",
+			input:    []string{"select 1;\r"},
+			expected: []editedLine{{text: "select 1;"}},
+		},
+		{
+			name:     "backspace and cursor movement",
+			input:    []string{"selct", "\x1b[D\x1b[D", "e", "\x1b[C\x1b[C", " 1;\x7f\x7f1;\r"},
+			expected: []editedLine{{text: "select 1;"}},
+		},
+		{
+			name:     "escape sequence split across reads",
+			input:    []string{"ab\x1b", "[D", "c\r"},
+			expected: []editedLine{{text: "acb"}},
+		},
+		{
+			name:     "ctrl-u and ctrl-w",
+			input:    []string{"drop table users\x15select * from users where id = 2\x17\x171;\r"},
+			expected: []editedLine{{text: "select * from users where id 1;"}},
+		},
+		{
+			name:     "ctrl-c discards the line",
+			input:    []string{"oops\x03\\dt\r"},
+			expected: []editedLine{{text: `\dt`}},
+		},
+		{
+			name:     "history makes the line uncertain",
+			input:    []string{"\x1b[A\r"},
+			expected: []editedLine{{text: "", uncertain: true}},
+		},
+		{
+			name:     "pasted lines",
+			input:    []string{"\x1b[200~def f():\n\treturn 1\n\x1b[201~"},
+			expected: []editedLine{{text: "def f():"}, {text: "\treturn 1"}},
+		},
+		{
+			name:     "multibyte characters",
+			input:    []string{"'h\xc3", "\xa9'\r"},
+			expected: []editedLine{{text: "'hé'"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var e lineEditor
+			var lines []editedLine
+			for _, in := range tc.input {
+				lines = append(lines, e.feed([]byte(in))...)
+			}
+			assert.Equal(t, tc.expected, lines)
+		})
+	}
+}
+
+func TestREPLSession(t *testing.T) {
+	srv := newTestServer(t)
+	input := srv.InputWriter()
+
+	// typeLine types s in the repl, which echoes it back.
+	typeLine := func(s string) {
+		for _, c := range s {
+			input.Write([]byte(string(c)))
+			srv.Write([]byte(string(c)))
+		}
+		input.Write([]byte("\r"))
+		srv.Write([]byte("\r\n"))
+	}
+
+	stepID := recordCommand(srv, "psql -U postgres app")
+	srv.Write([]byte("Password for user postgres: "))
+	// passwords aren't echoed.
+	input.Write([]byte("hunter2\r"))
+	srv.Write([]byte("\r\napp=# "))
+
+	typeLine("select count(*) from users;")
+	srv.Write([]byte(" count \r\n-------\r\n    42\r\n(1 row)\r\n\r\napp=# "))
+
+	// the user recalls the previous line and edits it.
+	input.Write([]byte("\x1b[A"))
+	srv.Write([]byte("select count(*) from users;"))
+	input.Write([]byte("\x7f\x7f\x7f\x7f\x7f\x7forders;"))
+	srv.Write([]byte("\b\b\b\b\b\b\x1b[Korders;"))
+	input.Write([]byte("\r"))
+	srv.Write([]byte("\r\n count \r\n-------\r\n     7\r\n(1 row)\r\n\r\napp=# "))
+
+	typeLine(`\q`)
+	srv.finishStep(RecordedData{StepID: stepID})
+
+	cmds := srv.Commands()
+	if assert.Len(t, cmds, 1) {
+		assert.Equal(t, []string{"select count(*) from users;", "select count(*) from orders;"}, cmds[0].SubSteps)
+	}
+
+	t.Run("input after the repl exits is not recorded", func(t *testing.T) {
+		typeLine("ls")
+		assert.Len(t, srv.Commands()[0].SubSteps, 2)
+	})
+}
+
+func TestREPLFinishMessage(t *testing.T) {
+	srv := newTestServer(t)
+	go srv.ListenAndServe()
+
+	hook, err := NewClient(context.Background(), srv.SocketPath())
+	assert.NoError(t, err)
+
+	stepID := idgen.New(idgen.CommandPrefix)
+	assert.NoError(t, hook.(*client).send(RecordedData{Command: "sqlite3 app.db", StepID: stepID}))
+	assert.Eventually(t, func() bool { return len(srv.Commands()) == 1 }, time.Second, 10*time.Millisecond)
+
+	input := srv.InputWriter()
+	srv.Write([]byte("sqlite> "))
+	input.Write([]byte("select 1;\r"))
+	srv.Write([]byte("select 1;\r\n1\r\nsqlite> "))
+
+	// a step of another terminal finishing doesn't end the repl.
+	assert.NoError(t, hook.(*client).send(RecordedData{StepID: idgen.New(idgen.CommandPrefix)}))
+
+	input.Write([]byte(".tables\r"))
+	srv.Write([]byte(".tables\r\nusers\r\nsqlite> "))
+	input.Write([]byte(".quit\r"))
+	srv.Write([]byte(".quit\r\n"))
+
+	assert.NoError(t, hook.(*client).send(RecordedData{StepID: stepID, ExitCode: 1}))
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{"select 1;", ".tables"}, srv.Commands()[0].SubSteps)
+	}, time.Second, 10*time.Millisecond)
+
+	// input after the finish message isn't recorded.
+	input.Write([]byte("ls\r"))
+	srv.Write([]byte("ls\r\n"))
+	assert.Len(t, srv.Commands()[0].SubSteps, 2)
+
+	t.Run("sub-steps sent by the shell hooks are ignored", func(t *testing.T) {
+		stepID := recordCommand(srv, "ssh prod")
+		assert.NoError(t, hook.(*client).send(RecordedData{StepID: stepID, SubSteps: []string{"uptime"}}))
+		assert.Never(t, func() bool { return len(srv.Commands()[1].SubSteps) > 0 }, 100*time.Millisecond, 10*time.Millisecond)
+	})
+}
@@ -0,0 +1,82 @@
+package run
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	savvy_client "github.com/getsavvyinc/savvy-cli/client"
+)
+
+// Assertion is the expected outcome of a step.
+type Assertion struct {
+	ExitCode int
+	Contains string
+	// Matches is matched against the output line by line.
+	Matches *regexp.Regexp
+	// Timeout is how long the step may take, or 0 if it may take as long as it needs.
+	Timeout time.Duration
+}
+
+// ParseAssertion parses the assertion of a step. Steps without an assertion return nil.
+func ParseAssertion(a *savvy_client.Assertion) (*Assertion, error) {
+	if a == nil {
+		return nil, nil
+	}
+
+	assertion := &Assertion{Contains: a.Contains}
+	if a.ExitCode != nil {
+		assertion.ExitCode = *a.ExitCode
+	}
+	if a.Matches != "" {
+		re, err := regexp.Compile("(?m)" + a.Matches)
+		if err != nil {
+			return nil, fmt.Errorf("invalid matches %q: %w", a.Matches, err)
+		}
+		assertion.Matches = re
+	}
+	if a.Timeout != "" {
+		timeout, err := time.ParseDuration(a.Timeout)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid timeout %q: expected a duration e.g 30s or 5m", a.Timeout)
+		}
+		assertion.Timeout = timeout
+	}
+	return assertion, nil
+}
+
+// Check returns how a step that exited with exitCode after duration and printed output differs from the assertion.
+func (a *Assertion) Check(exitCode int, output string, duration time.Duration) []string {
+	var mismatches []string
+	if exitCode != a.ExitCode {
+		mismatches = append(mismatches, fmt.Sprintf("exited with %d, expected %d", exitCode, a.ExitCode))
+	}
+	if a.Contains != "" && !strings.Contains(output, a.Contains) {
+		mismatches = append(mismatches, fmt.Sprintf("output doesn't contain %q", a.Contains))
+	}
+	if a.Matches != nil && !a.Matches.MatchString(output) {
+		mismatches = append(mismatches, fmt.Sprintf("output doesn't match %q", strings.TrimPrefix(a.Matches.String(), "(?m)")))
+	}
+	if a.Timeout > 0 && duration > a.Timeout {
+		mismatches = append(mismatches, fmt.Sprintf("didn't finish within the %s timeout", a.Timeout))
+	}
+	return mismatches
+}
+
+// Mismatch describes a step whose last run didn't do what its assertion expects.
+type Mismatch struct {
+	Index      int      `json:"index"`
+	Mismatches []string `json:"mismatches"`
+}
+
+func (m *Mismatch) String() string {
+	return fmt.Sprintf("Step %d didn't pass its assertion: %s", m.Index+1, strings.Join(m.Mismatches, ", "))
+}
+
+// assertedStep is the step whose assertion is checked once the command that runs it finishes.
+type assertedStep struct {
+	index   int
+	started time.Time
+	output  tailBuffer
+}
@@ -0,0 +1,158 @@
+package run
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	savvy_client "github.com/getsavvyinc/savvy-cli/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssertionCheck(t *testing.T) {
+	exitCode := 1
+
+	testCases := []struct {
+		name               string
+		assert             *savvy_client.Assertion
+		exitCode           int
+		output             string
+		duration           time.Duration
+		expectedMismatches []string
+		expectedErr        bool
+	}{
+		{
+			name:     "contains",
+			assert:   &savvy_client.Assertion{Contains: "successfully rolled out"},
+			output:   `deployment "api" successfully rolled out`,
+			duration: time.Second,
+		},
+		{
+			name:               "doesn't contain",
+			assert:             &savvy_client.Assertion{Contains: "successfully rolled out"},
+			output:             "Waiting for deployment rollout to finish",
+			expectedMismatches: []string{`output doesn't contain "successfully rolled out"`},
+		},
+		{
+			name:   "matches a line",
+			assert: &savvy_client.Assertion{Matches: `^Status: (Running|Completed)$`},
+			output: "Name: api\nStatus: Running\n",
+		},
+		{
+			name:               "doesn't match",
+			assert:             &savvy_client.Assertion{Matches: `^Status: Running$`},
+			output:             "Status: Pending\n",
+			expectedMismatches: []string{`output doesn't match "^Status: Running$"`},
+		},
+		{
+			name:               "non-zero exit code",
+			assert:             &savvy_client.Assertion{Contains: "ok"},
+			exitCode:           2,
+			output:             "ok",
+			expectedMismatches: []string{"exited with 2, expected 0"},
+		},
+		{
+			name:     "expected exit code",
+			assert:   &savvy_client.Assertion{ExitCode: &exitCode},
+			exitCode: 1,
+		},
+		{
+			name:               "timeout",
+			assert:             &savvy_client.Assertion{Timeout: "1m"},
+			duration:           2 * time.Minute,
+			expectedMismatches: []string{"didn't finish within the 1m0s timeout"},
+		},
+		{
+			name:        "invalid regex",
+			assert:      &savvy_client.Assertion{Matches: "("},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid timeout",
+			assert:      &savvy_client.Assertion{Timeout: "5 minutes"},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assertion, err := ParseAssertion(tc.assert)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedMismatches, assertion.Check(tc.exitCode, tc.output, tc.duration))
+		})
+	}
+}
+
+func TestAssertions(t *testing.T) {
+	rb := &savvy_client.Runbook{
+		Steps: []savvy_client.Step{
+			{Command: "echo rolling out", Assert: &savvy_client.Assertion{Contains: "successfully rolled out"}},
+			{Command: "echo successfully rolled out", Assert: &savvy_client.Assertion{Contains: "successfully rolled out"}},
+			{Command: "sleep 5", Assert: &savvy_client.Assertion{Timeout: "100ms"}},
+		},
+	}
+
+	t.Run("run", func(t *testing.T) {
+		srv, cl, cleanup := newTestServerWithClient(t, rb)
+		t.Cleanup(func() { cleanup() })
+
+		// the output of the step comes from the pty of the run session.
+		assert.NoError(t, cl.StartCommand("echo rolling out"))
+		assert.NoError(t, cl.NextCommand())
+		_, err := cl.CurrentState()
+		assert.NoError(t, err)
+		srv.Write([]byte("rolling out\r\n"))
+		assert.NoError(t, cl.FinishCommand(0, ""))
+
+		st, err := cl.CurrentState()
+		assert.NoError(t, err)
+		if assert.NotNil(t, st.Mismatch) {
+			assert.Equal(t, 0, st.Mismatch.Index)
+			assert.Equal(t, `Step 1 didn't pass its assertion: output doesn't contain "successfully rolled out"`, st.Mismatch.String())
+		}
+
+		// the mismatch is cleared once another prompt shows up.
+		assert.NoError(t, cl.FinishCommand(0, ""))
+		st, err = cl.CurrentState()
+		assert.NoError(t, err)
+		assert.Nil(t, st.Mismatch)
+
+		assert.NoError(t, cl.StartCommand("echo successfully rolled out"))
+		assert.NoError(t, cl.NextCommand())
+		_, err = cl.CurrentState()
+		assert.NoError(t, err)
+		srv.Write([]byte("successfully rolled out\r\n"))
+		assert.NoError(t, cl.FinishCommand(0, ""))
+
+		st, err = cl.CurrentState()
+		assert.NoError(t, err)
+		assert.Nil(t, st.Mismatch)
+	})
+
+	t.Run("exec", func(t *testing.T) {
+		_, cl, cleanup := newTestServerWithClient(t, rb)
+		t.Cleanup(func() { cleanup() })
+
+		var stdout, stderr bytes.Buffer
+		e := NewExecutor(cl, WithOutput(&stdout, &stderr), WithContinueOnError(true))
+		result, err := e.Run(context.Background())
+		assert.NoError(t, err)
+
+		failed := result.Failed()
+		if assert.Len(t, failed, 2) {
+			assert.Equal(t, 0, failed[0].Index)
+			assert.Equal(t, 2, failed[1].Index)
+			assert.Contains(t, failed[1].Mismatches, "didn't finish within the 100ms timeout")
+			assert.Less(t, failed[1].Duration, 5*time.Second)
+		}
+		// the first step exited with 0, but didn't pass its assertion.
+		assert.Equal(t, 1, result.ExitCode())
+		assert.True(t, strings.Contains(stderr.String(), `Step 1 didn't pass its assertion: output doesn't contain "successfully rolled out"`))
+	})
+}
@@ -0,0 +1,269 @@
+package run
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	savvy_client "github.com/getsavvyinc/savvy-cli/client"
+	"github.com/getsavvyinc/savvy-cli/config"
+)
+
+// DefaultAuditDir is where the audit logs of runs are written.
+var DefaultAuditDir = filepath.Join(config.DefaultConfigDir, "audit")
+
+const auditLogExt = ".jsonl"
+
+type AuditEntryType string
+
+const (
+	AuditRunStarted AuditEntryType = "run_started"
+	AuditRunResumed AuditEntryType = "run_resumed"
+	AuditStep       AuditEntryType = "step"
+	AuditRunEnded   AuditEntryType = "run_ended"
+)
+
+type Match string
+
+const (
+	// MatchMatched means the command that ran is the step of the runbook.
+	MatchMatched Match = "matched"
+	// MatchEdited means the command that ran differs from the step of the runbook.
+	MatchEdited Match = "edited"
+)
+
+// redactedValue replaces the values of secret params in the audit log.
+const redactedValue = "<redacted>"
+
+// AuditEntry is a line of the audit log of a run.
+// Run entries describe who ran which runbook, step entries describe a command that ran during the run.
+type AuditEntry struct {
+	Type AuditEntryType `json:"type"`
+	Time time.Time      `json:"time"`
+
+	RunID     string `json:"run_id,omitempty"`
+	RunbookID string `json:"runbook_id,omitempty"`
+	Title     string `json:"title,omitempty"`
+	User      string `json:"user,omitempty"`
+	Host      string `json:"host,omitempty"`
+	// Mode is either interactive or exec.
+	Mode string `json:"mode,omitempty"`
+
+	// StepNumber is the 1-based number of the step that was current when the command ran.
+	StepNumber int `json:"step_number,omitempty"`
+	// Step is the step of the runbook with its params set.
+	Step    string `json:"step,omitempty"`
+	Command string `json:"command,omitempty"`
+	Match   Match  `json:"match,omitempty"`
+	// Params are the params used by the step. Values of secret params are redacted.
+	Params     map[string]string `json:"params,omitempty"`
+	FinishedAt *time.Time        `json:"finished_at,omitempty"`
+	ExitCode   *int              `json:"exit_code,omitempty"`
+	Output     string            `json:"output,omitempty"`
+}
+
+// AuditLog is an append-only JSONL transcript of a run.
+// A resumed run appends to the audit log of the run it resumes.
+type AuditLog struct {
+	runID string
+	path  string
+	// withOutput is true if the output of commands is logged.
+	withOutput bool
+
+	mu sync.Mutex
+	f  *os.File
+}
+
+// NewAuditLog opens the audit log of the run.
+func NewAuditLog(runID string, withOutput bool) (*AuditLog, error) {
+	if err := os.MkdirAll(DefaultAuditDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create audit dir: %w", err)
+	}
+
+	path := filepath.Join(DefaultAuditDir, runID+auditLogExt)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &AuditLog{runID: runID, path: path, withOutput: withOutput, f: f}, nil
+}
+
+// Path returns the path of the audit log file.
+func (l *AuditLog) Path() string {
+	return l.path
+}
+
+// RunStarted logs who started the run of rb. mode is either interactive or exec.
+func (l *AuditLog) RunStarted(rb *savvy_client.Runbook, mode string, resumed bool) error {
+	if l == nil {
+		return nil
+	}
+
+	entry := AuditEntry{
+		Type:      AuditRunStarted,
+		Time:      time.Now(),
+		RunID:     l.runID,
+		RunbookID: rb.RunbookID,
+		Title:     rb.Title,
+		User:      currentUser(),
+		Mode:      mode,
+	}
+	if resumed {
+		entry.Type = AuditRunResumed
+	}
+	entry.Host, _ = os.Hostname()
+	return l.append(entry)
+}
+
+// RunEnded logs the end of the run.
+func (l *AuditLog) RunEnded() error {
+	if l == nil {
+		return nil
+	}
+	return l.append(AuditEntry{Type: AuditRunEnded, Time: time.Now(), RunID: l.runID})
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+func (l *AuditLog) append(entry AuditEntry) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.f == nil {
+		return errors.New("audit log is closed")
+	}
+
+	bs, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := l.f.Write(append(bs, '\n')); err != nil {
+		return err
+	}
+	return l.f.Sync()
+}
+
+// Close closes the audit log file.
+func (l *AuditLog) Close() error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.f == nil {
+		return nil
+	}
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
+
+// AuditedRun summarizes the audit log of a run.
+type AuditedRun struct {
+	RunID     string
+	Title     string
+	User      string
+	StartedAt time.Time
+	Commands  int
+	// Failed is the number of commands that exited with a non-zero exit code.
+	Failed int
+	Ended  bool
+}
+
+// AuditedRuns returns the runs that have an audit log. The most recent run is returned first.
+func AuditedRuns() ([]*AuditedRun, error) {
+	paths, err := filepath.Glob(filepath.Join(DefaultAuditDir, "*"+auditLogExt))
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []*AuditedRun
+	for _, path := range paths {
+		entries, err := readAuditLog(path)
+		if err != nil || len(entries) == 0 {
+			continue
+		}
+
+		run := &AuditedRun{RunID: strings.TrimSuffix(filepath.Base(path), auditLogExt)}
+		for _, entry := range entries {
+			switch entry.Type {
+			case AuditRunStarted, AuditRunResumed:
+				if run.StartedAt.IsZero() {
+					run.Title, run.User, run.StartedAt = entry.Title, entry.User, entry.Time
+				}
+				run.Ended = false
+			case AuditStep:
+				run.Commands++
+				if entry.ExitCode != nil && *entry.ExitCode != 0 {
+					run.Failed++
+				}
+			case AuditRunEnded:
+				run.Ended = true
+			}
+		}
+		runs = append(runs, run)
+	}
+
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].StartedAt.After(runs[j].StartedAt)
+	})
+	return runs, nil
+}
+
+var ErrAuditLogNotFound = errors.New("audit log not found")
+
+// ReadAuditLog returns the entries of the audit log of the run.
+func ReadAuditLog(runID string) ([]AuditEntry, error) {
+	path := filepath.Join(DefaultAuditDir, filepath.Base(runID)+auditLogExt)
+	entries, err := readAuditLog(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrAuditLogNotFound, runID)
+	}
+	return entries, err
+}
+
+func readAuditLog(path string) ([]AuditEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(f)
+	// logged output can be large
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var entry AuditEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			// A crash may leave a partially written last line. Keep everything before it.
+			break
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
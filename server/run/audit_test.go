@@ -0,0 +1,111 @@
+package run
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	savvy_client "github.com/getsavvyinc/savvy-cli/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditLog(t *testing.T) {
+	defaultAuditDir := DefaultAuditDir
+	DefaultAuditDir = t.TempDir()
+	t.Cleanup(func() { DefaultAuditDir = defaultAuditDir })
+
+	rb := &savvy_client.Runbook{
+		RunbookID: "rb-test",
+		Title:     "test",
+		Steps: []savvy_client.Step{
+			{Command: "echo <greeting> <token!>"},
+			{Command: "exit 2"},
+		},
+	}
+
+	t.Run("exec", func(t *testing.T) {
+		audit, err := NewAuditLog("run-exec", true)
+		assert.NoError(t, err)
+		assert.NoError(t, audit.RunStarted(rb, "exec", false))
+
+		_, cl, cleanup := newTestServerWithClient(t, rb, WithAuditLog(audit))
+		t.Cleanup(func() { cleanup() })
+		assert.NoError(t, cl.SetParams(map[string]string{"<greeting>": "hi", "<token>": "secret"}))
+
+		_, err = NewExecutor(cl, WithOutput(&bytes.Buffer{}, &bytes.Buffer{})).Run(context.Background())
+		assert.NoError(t, err)
+		// wait for the server to handle the commands.
+		_, err = cl.CurrentState()
+		assert.NoError(t, err)
+		assert.NoError(t, audit.RunEnded())
+		assert.NoError(t, audit.Close())
+
+		entries, err := ReadAuditLog("run-exec")
+		assert.NoError(t, err)
+		if !assert.Len(t, entries, 4) {
+			return
+		}
+
+		assert.Equal(t, AuditRunStarted, entries[0].Type)
+		assert.Equal(t, "rb-test", entries[0].RunbookID)
+		assert.NotEmpty(t, entries[0].User)
+
+		first := entries[1]
+		assert.Equal(t, AuditStep, first.Type)
+		assert.Equal(t, 1, first.StepNumber)
+		assert.Equal(t, "echo hi <redacted>", first.Command, "secret values are redacted")
+		assert.Equal(t, MatchMatched, first.Match)
+		assert.Equal(t, map[string]string{"<greeting>": "hi", "<token>": redactedValue}, first.Params)
+		assert.Equal(t, 0, *first.ExitCode)
+		assert.Equal(t, "hi <redacted>", first.Output)
+
+		assert.Equal(t, 2, *entries[2].ExitCode)
+		assert.Equal(t, AuditRunEnded, entries[3].Type)
+
+		runs, err := AuditedRuns()
+		assert.NoError(t, err)
+		if assert.Len(t, runs, 1) {
+			assert.Equal(t, &AuditedRun{
+				RunID:     "run-exec",
+				Title:     "test",
+				User:      entries[0].User,
+				StartedAt: entries[0].Time,
+				Commands:  2,
+				Failed:    1,
+				Ended:     true,
+			}, runs[0])
+		}
+	})
+
+	t.Run("edited command", func(t *testing.T) {
+		audit, err := NewAuditLog("run-interactive", false)
+		assert.NoError(t, err)
+		t.Cleanup(func() { audit.Close() })
+
+		srv, cl, cleanup := newTestServerWithClient(t, rb, WithAuditLog(audit))
+		t.Cleanup(func() { cleanup() })
+		assert.NoError(t, cl.SetParams(map[string]string{"<greeting>": "hi", "<token>": "secret"}))
+
+		assert.NoError(t, cl.StartCommand("echo hello secret"))
+		srv.Write([]byte("hello secret\r\n"))
+		assert.NoError(t, cl.FinishCommand(0, ""))
+		// commands only finish once.
+		assert.NoError(t, cl.FinishCommand(1, ""))
+		_, err = cl.CurrentState()
+		assert.NoError(t, err)
+
+		entries, err := ReadAuditLog("run-interactive")
+		assert.NoError(t, err)
+		if assert.Len(t, entries, 1) {
+			assert.Equal(t, MatchEdited, entries[0].Match)
+			assert.Equal(t, "echo hi <redacted>", entries[0].Step)
+			assert.Equal(t, 0, *entries[0].ExitCode)
+			assert.Empty(t, entries[0].Output, "output is only logged if asked for")
+		}
+	})
+
+	t.Run("unknown run", func(t *testing.T) {
+		_, err := ReadAuditLog("run-unknown")
+		assert.ErrorIs(t, err, ErrAuditLogNotFound)
+	})
+}
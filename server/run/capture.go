@@ -0,0 +1,103 @@
+package run
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/getsavvyinc/savvy-cli/param"
+)
+
+// captureDirective is a comment at the end of a step that captures a value from the output of the step into a param
+// that later steps can use e.g
+//
+//	kubectl create namespace test-$RANDOM -o name # savvy:capture namespace=namespace/(.+)
+//
+// Without a regular expression, the last line of the output is captured.
+const captureDirective = "# savvy:capture"
+
+// Capture sets a param to a value found in the output of a step.
+type Capture struct {
+	// Param is the key of the param e.g <namespace>.
+	Param string
+	// Pattern finds the value in the output. The first group is captured if there is one, otherwise the whole match.
+	Pattern *regexp.Regexp
+}
+
+// parseCapture returns command without its capture directive, and the capture the directive describes.
+// Commands without a valid directive are returned as is.
+func parseCapture(command string) (string, *Capture) {
+	lineStart := strings.LastIndexByte(command, '\n') + 1
+	idx := strings.LastIndex(command[lineStart:], captureDirective)
+	if idx < 0 {
+		return command, nil
+	}
+	idx += lineStart
+	// the directive must be a comment of its own, not part of a word.
+	if idx > 0 && command[idx-1] != ' ' && command[idx-1] != '\t' && command[idx-1] != '\n' {
+		return command, nil
+	}
+
+	spec := strings.TrimSpace(command[idx+len(captureDirective):])
+	name, pattern, hasPattern := strings.Cut(spec, "=")
+	key, err := param.Key(name)
+	if err != nil {
+		return command, nil
+	}
+
+	capture := &Capture{Param: key}
+	if hasPattern {
+		// the output is matched line by line.
+		if capture.Pattern, err = regexp.Compile("(?m)" + pattern); err != nil {
+			return command, nil
+		}
+	}
+	return strings.TrimRight(command[:idx], " \t"), capture
+}
+
+// find returns the value the capture finds in the output of a step.
+func (c *Capture) find(output string) (string, bool) {
+	if c.Pattern == nil {
+		lines := strings.Split(output, "\n")
+		for i := len(lines) - 1; i >= 0; i-- {
+			if line := strings.TrimSpace(lines[i]); line != "" {
+				return line, true
+			}
+		}
+		return "", false
+	}
+
+	match := c.Pattern.FindStringSubmatch(output)
+	switch {
+	case match == nil:
+		return "", false
+	case len(match) > 1:
+		return strings.TrimSpace(match[1]), true
+	default:
+		return strings.TrimSpace(match[0]), true
+	}
+}
+
+// maxCaptureOutputSize caps the output of a step that is kept to evaluate its capture.
+const maxCaptureOutputSize = 64 * 1024
+
+// tailBuffer keeps the last maxCaptureOutputSize bytes written to it.
+type tailBuffer struct {
+	buf []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	b.buf = append(b.buf, p...)
+	if extra := len(b.buf) - maxCaptureOutputSize; extra > 0 {
+		b.buf = append(b.buf[:0], b.buf[extra:]...)
+	}
+	return n, nil
+}
+
+func (b *tailBuffer) String() string {
+	return string(b.buf)
+}
+
+func (b *tailBuffer) Reset() {
+	b.buf = b.buf[:0]
+}
@@ -0,0 +1,91 @@
+package run
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCapture(t *testing.T) {
+	testCases := []struct {
+		name            string
+		command         string
+		expectedCommand string
+		expectedParam   string
+		expectedPattern string
+	}{
+		{
+			name:            "last line of the output",
+			command:         "kubectl get pods -o name | head -1 # savvy:capture pod",
+			expectedCommand: "kubectl get pods -o name | head -1",
+			expectedParam:   "<pod>",
+		},
+		{
+			name:            "regex",
+			command:         "gh release create v1 # savvy:capture url=(https://\\S+)",
+			expectedCommand: "gh release create v1",
+			expectedParam:   "<url>",
+			expectedPattern: "(?m)(https://\\S+)",
+		},
+		{
+			name:            "no directive",
+			command:         "echo hello # a comment",
+			expectedCommand: "echo hello # a comment",
+		},
+		{
+			name:            "invalid param name",
+			command:         "echo hello # savvy:capture a b",
+			expectedCommand: "echo hello # savvy:capture a b",
+		},
+		{
+			name:            "directive in an earlier line",
+			command:         "echo a # savvy:capture a\necho b",
+			expectedCommand: "echo a # savvy:capture a\necho b",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			command, capture := parseCapture(tc.command)
+			assert.Equal(t, tc.expectedCommand, command)
+			if tc.expectedParam == "" {
+				assert.Nil(t, capture)
+				return
+			}
+			if assert.NotNil(t, capture) {
+				assert.Equal(t, tc.expectedParam, capture.Param)
+				if tc.expectedPattern != "" {
+					assert.Equal(t, tc.expectedPattern, capture.Pattern.String())
+				}
+			}
+		})
+	}
+}
+
+func TestCaptureFind(t *testing.T) {
+	output := "Creating release...\nhttps://github.com/acme/app/releases/v1\n\n"
+
+	testCases := []struct {
+		name     string
+		spec     string
+		expected string
+		found    bool
+	}{
+		{name: "last line", spec: "url", expected: "https://github.com/acme/app/releases/v1", found: true},
+		{name: "group", spec: `tag=releases/(\S+)`, expected: "v1", found: true},
+		{name: "whole match", spec: `url=^https://\S+$`, expected: "https://github.com/acme/app/releases/v1", found: true},
+		{name: "no match", spec: "url=gitlab", found: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, capture := parseCapture("gh release create # savvy:capture " + tc.spec)
+			if !assert.NotNil(t, capture) {
+				return
+			}
+			value, found := capture.find(output)
+			assert.Equal(t, tc.found, found)
+			assert.Equal(t, tc.expected, value)
+		})
+	}
+}
@@ -12,12 +12,29 @@ type Client interface {
 	server.ShutdownSender
 	NextCommand() error
 	PreviousCommand() error
+	// SkipCommand moves to the next step without running the current one.
+	SkipCommand() error
+	// GoToCommand moves to the step at index. Indexes past the last step end the run.
+	GoToCommand(index int) error
+	ListCommands() ([]Step, error)
+	// ConfirmCommand confirms that the dangerous step at index may run.
+	ConfirmCommand(index int) error
 	CurrentState() (*State, error)
+	// SetParams sets the params that aren't set yet.
 	SetParams(params map[string]string) error
+	// OverrideParams sets params even if they are already set e.g the values passed with --param to a resumed run.
+	OverrideParams(params map[string]string) error
+	// StartCommand reports a command that started running during the run session, and FinishCommand reports how it
+	// finished. output is only needed for commands that run outside the pty of the run session.
+	StartCommand(command string) error
+	FinishCommand(exitCode int, output string) error
+	// SendOutput reports the output of the step at index, so that it can set a param.
+	SendOutput(index int, output string) error
 }
 
+// NewDefaultClient returns a client for the run session of the current shell.
 func NewDefaultClient(ctx context.Context) (Client, error) {
-	return NewClient(ctx, DefaultRunSocketPath)
+	return NewClient(ctx, SocketPathFromEnv())
 }
 
 type client struct {
@@ -82,7 +99,66 @@ func (c *client) PreviousCommand() error {
 	return json.NewEncoder(conn).Encode(data)
 }
 
+func (c *client) SkipCommand() error {
+	conn, err := net.Dial("unix", c.socketPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	data := RunCommand{
+		Command: skipCommand,
+	}
+
+	return json.NewEncoder(conn).Encode(data)
+}
+
+func (c *client) GoToCommand(index int) error {
+	conn, err := net.Dial("unix", c.socketPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	data := RunCommand{
+		Command: gotoCommand,
+		Index:   index,
+	}
+
+	return json.NewEncoder(conn).Encode(data)
+}
+
+func (c *client) ListCommands() ([]Step, error) {
+	conn, err := net.Dial("unix", c.socketPath)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	data := RunCommand{
+		Command: listCommand,
+	}
+
+	if err := json.NewEncoder(conn).Encode(data); err != nil {
+		return nil, err
+	}
+
+	var steps []Step
+	if err := json.NewDecoder(conn).Decode(&steps); err != nil {
+		return nil, err
+	}
+	return steps, nil
+}
+
 func (c *client) SetParams(params map[string]string) error {
+	return c.sendParams(paramCommand, params)
+}
+
+func (c *client) OverrideParams(params map[string]string) error {
+	return c.sendParams(overrideParamCommand, params)
+}
+
+func (c *client) sendParams(command string, params map[string]string) error {
 	conn, err := net.Dial("unix", c.socketPath)
 	if err != nil {
 		return err
@@ -90,7 +166,7 @@ func (c *client) SetParams(params map[string]string) error {
 	defer conn.Close()
 
 	data := RunCommand{
-		Command: paramCommand,
+		Command: command,
 		Params:  params,
 	}
 
@@ -121,3 +197,65 @@ func (c *client) CurrentState() (*State, error) {
 	}
 	return &response, nil
 }
+
+func (c *client) SendOutput(index int, output string) error {
+	conn, err := net.Dial("unix", c.socketPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	data := RunCommand{
+		Command: outputCommand,
+		Index:   index,
+		Output:  output,
+	}
+
+	return json.NewEncoder(conn).Encode(data)
+}
+
+func (c *client) StartCommand(command string) error {
+	conn, err := net.Dial("unix", c.socketPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	data := RunCommand{
+		Command:  startedCommand,
+		Executed: command,
+	}
+
+	return json.NewEncoder(conn).Encode(data)
+}
+
+func (c *client) FinishCommand(exitCode int, output string) error {
+	conn, err := net.Dial("unix", c.socketPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	data := RunCommand{
+		Command:  finishedCommand,
+		ExitCode: exitCode,
+		Output:   output,
+	}
+
+	return json.NewEncoder(conn).Encode(data)
+}
+
+func (c *client) ConfirmCommand(index int) error {
+	conn, err := net.Dial("unix", c.socketPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	data := RunCommand{
+		Command: confirmCommand,
+		Index:   index,
+	}
+
+	return json.NewEncoder(conn).Encode(data)
+}
@@ -0,0 +1,109 @@
+package run
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	savvy_client "github.com/getsavvyinc/savvy-cli/client"
+	"github.com/getsavvyinc/savvy-cli/danger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDangerousSteps(t *testing.T) {
+	classifier, err := danger.NewClassifier(&danger.Rules{
+		Rules: []danger.Rule{{Pattern: `^drop-db`, Reason: "drops the database"}},
+	})
+	assert.NoError(t, err)
+
+	rb := &savvy_client.Runbook{
+		Steps: []savvy_client.Step{
+			{Command: "echo hello"},
+			{Command: "drop-db <db>"},
+		},
+	}
+
+	t.Run("confirm", func(t *testing.T) {
+		_, cl, cleanup := newTestServerWithClient(t, rb, WithClassifier(classifier))
+		t.Cleanup(func() { cleanup() })
+
+		st, err := cl.CurrentState()
+		assert.NoError(t, err)
+		assert.Empty(t, st.Danger)
+		assert.False(t, st.NeedsConfirmation())
+
+		steps, err := cl.ListCommands()
+		assert.NoError(t, err)
+		assert.Equal(t, "drops the database", steps[1].Danger)
+
+		assert.NoError(t, cl.SetParams(map[string]string{"<db>": "prod"}))
+		assert.NoError(t, cl.NextCommand())
+		st, err = cl.CurrentState()
+		assert.NoError(t, err)
+		assert.Equal(t, "drops the database", st.Danger)
+		assert.True(t, st.NeedsConfirmation())
+
+		assert.NoError(t, cl.ConfirmCommand(st.Index))
+		st, err = cl.CurrentState()
+		assert.NoError(t, err)
+		assert.True(t, st.Confirmed)
+		assert.False(t, st.NeedsConfirmation())
+	})
+
+	t.Run("exec", func(t *testing.T) {
+		testCases := []struct {
+			name             string
+			confirm          ConfirmFunc
+			expectedDeclined bool
+			expectedExitCode int
+		}{
+			{
+				name:             "no confirm func",
+				expectedDeclined: true,
+				expectedExitCode: 1,
+			},
+			{
+				name:             "declined",
+				confirm:          func(*State) (bool, error) { return false, nil },
+				expectedDeclined: true,
+				expectedExitCode: 1,
+			},
+			{
+				name:    "confirmed",
+				confirm: func(*State) (bool, error) { return true, nil },
+			},
+		}
+
+		rb := &savvy_client.Runbook{
+			Steps: []savvy_client.Step{
+				{Command: "echo hello"},
+				{Command: "drop-db() { echo dropped; }; drop-db"},
+			},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				_, cl, cleanup := newTestServerWithClient(t, rb, WithClassifier(classifier))
+				t.Cleanup(func() { cleanup() })
+
+				var stdout, stderr bytes.Buffer
+				opts := []ExecOption{WithOutput(&stdout, &stderr)}
+				if tc.confirm != nil {
+					opts = append(opts, WithConfirm(tc.confirm))
+				}
+				result, err := NewExecutor(cl, opts...).Run(context.Background())
+				assert.NoError(t, err)
+
+				assert.Equal(t, tc.expectedDeclined, result.Declined)
+				assert.Equal(t, tc.expectedExitCode, result.ExitCode())
+				assert.True(t, strings.Contains(stderr.String(), "Step 2 is flagged as dangerous: drops the database"))
+				if tc.expectedDeclined {
+					assert.Equal(t, "hello\n", stdout.String())
+				} else {
+					assert.Equal(t, "hello\ndropped\n", stdout.String())
+				}
+			})
+		}
+	})
+}
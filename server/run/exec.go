@@ -0,0 +1,317 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/getsavvyinc/savvy-cli/server"
+)
+
+// Executor runs the steps of a run session one after the other without user interaction.
+// It drives the session through a Client, so the session state stays in sync with the steps that ran.
+type Executor struct {
+	cl              Client
+	shell           string
+	stdout          io.Writer
+	stderr          io.Writer
+	continueOnError bool
+	env             []string
+	confirm         ConfirmFunc
+}
+
+// ConfirmFunc asks whether the dangerous step in state may run.
+type ConfirmFunc func(state *State) (bool, error)
+
+type ExecOption func(e *Executor)
+
+// WithContinueOnError keeps running the remaining steps after a step fails.
+func WithContinueOnError(continueOnError bool) ExecOption {
+	return func(e *Executor) {
+		e.continueOnError = continueOnError
+	}
+}
+
+// WithOutput sets where the output of the steps and the progress of the run are written.
+func WithOutput(stdout, stderr io.Writer) ExecOption {
+	return func(e *Executor) {
+		e.stdout = stdout
+		e.stderr = stderr
+	}
+}
+
+// WithShell sets the shell that runs the steps. It must accept -c and POSIX syntax.
+func WithShell(shell string) ExecOption {
+	return func(e *Executor) {
+		e.shell = shell
+	}
+}
+
+// WithEnv adds environment variables to the environment of the steps.
+func WithEnv(env ...string) ExecOption {
+	return func(e *Executor) {
+		e.env = append(e.env, env...)
+	}
+}
+
+// WithConfirm asks confirm before a dangerous step runs. Without it, the run stops at the first dangerous step.
+func WithConfirm(confirm ConfirmFunc) ExecOption {
+	return func(e *Executor) {
+		e.confirm = confirm
+	}
+}
+
+func NewExecutor(cl Client, opts ...ExecOption) *Executor {
+	e := &Executor{
+		cl:     cl,
+		shell:  defaultExecShell(),
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+func defaultExecShell() string {
+	if bash, err := exec.LookPath("bash"); err == nil {
+		return bash
+	}
+	return "/bin/sh"
+}
+
+// StepResult describes how a step ran.
+type StepResult struct {
+	Index    int
+	Command  string
+	ExitCode int
+	Duration time.Duration
+	// Mismatches describe how a step with an assertion differs from what its assertion expects.
+	Mismatches []string
+
+	asserted bool
+}
+
+// Failed reports whether the step didn't pass its assertion, or exited with a non-zero exit code if it has none.
+func (s StepResult) Failed() bool {
+	if s.asserted {
+		return len(s.Mismatches) > 0
+	}
+	return s.ExitCode != 0
+}
+
+// ExecResult describes how the steps of a run session ran.
+type ExecResult struct {
+	Steps []StepResult
+	// Interrupted is true if the run was canceled before all steps ran.
+	Interrupted bool
+	// Declined is true if the run stopped at a dangerous step that wasn't confirmed.
+	Declined bool
+}
+
+// Failed returns the steps that failed.
+func (r *ExecResult) Failed() []StepResult {
+	var failed []StepResult
+	for _, step := range r.Steps {
+		if step.Failed() {
+			failed = append(failed, step)
+		}
+	}
+	return failed
+}
+
+// ExitCode returns the exit code of the first step that failed, or 0 if all steps succeeded.
+// Steps that failed their assertion with a zero exit code exit with 1.
+// Interrupted runs exit with 130, like shells do on ctrl-c.
+func (r *ExecResult) ExitCode() int {
+	if r.Interrupted {
+		return 130
+	}
+	if r.Declined {
+		return 1
+	}
+	if failed := r.Failed(); len(failed) > 0 {
+		return max(failed[0].ExitCode, 1)
+	}
+	return 0
+}
+
+// Run runs the steps from the current step of the session until the last one.
+//
+// Each step runs in a child shell. The working directory and exported variables of a step are passed on to the next
+// step, so that steps like cd or export behave the same way they do when the runbook is run interactively.
+func (e *Executor) Run(ctx context.Context) (*ExecResult, error) {
+	stateDir, err := os.MkdirTemp("", "savvy-exec-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create state dir: %w", err)
+	}
+	defer os.RemoveAll(stateDir)
+
+	ss := &shellState{
+		envFile: filepath.Join(stateDir, "env"),
+		dirFile: filepath.Join(stateDir, "dir"),
+	}
+
+	result := &ExecResult{}
+	for {
+		if ctx.Err() != nil {
+			result.Interrupted = true
+			return result, nil
+		}
+
+		state, err := e.cl.CurrentState()
+		if err != nil {
+			return result, fmt.Errorf("failed to get the current step: %w", err)
+		}
+		// The session is done once the index moves past the last step.
+		if state.Command == "" {
+			return result, nil
+		}
+
+		command := state.CommandWithSetParams()
+		if state.NeedsConfirmation() {
+			fmt.Fprintf(e.stderr, "==> Step %d is flagged as dangerous: %s\n", state.Index+1, state.Danger)
+			ok, err := e.confirmStep(state)
+			if err != nil {
+				return result, err
+			}
+			if !ok {
+				fmt.Fprintf(e.stderr, "==> Step %d was not confirmed\n", state.Index+1)
+				result.Declined = true
+				return result, nil
+			}
+			if err := e.cl.ConfirmCommand(state.Index); err != nil {
+				return result, fmt.Errorf("failed to confirm step %d: %w", state.Index+1, err)
+			}
+		}
+		fmt.Fprintf(e.stderr, "==> Step %d: %s\n", state.Index+1, command)
+
+		if err := e.cl.StartCommand(command); err != nil {
+			return result, fmt.Errorf("failed to start step %d: %w", state.Index+1, err)
+		}
+
+		assertion, err := ParseAssertion(state.Assert)
+		if err != nil {
+			return result, fmt.Errorf("step %d: %w", state.Index+1, err)
+		}
+
+		var output tailBuffer
+		step, err := e.runStep(ctx, ss, state.Index, command, assertion, &output)
+		if err != nil {
+			return result, err
+		}
+		if assertion != nil {
+			step.asserted = true
+			step.Mismatches = assertion.Check(step.ExitCode, server.CleanOutput(output.String()), step.Duration)
+		}
+		result.Steps = append(result.Steps, step)
+
+		if err := e.cl.FinishCommand(step.ExitCode, output.String()); err != nil {
+			return result, fmt.Errorf("failed to finish step %d: %w", state.Index+1, err)
+		}
+		if err := e.cl.SendOutput(state.Index, output.String()); err != nil {
+			return result, fmt.Errorf("failed to send the output of step %d: %w", state.Index+1, err)
+		}
+
+		if ctx.Err() != nil {
+			result.Interrupted = true
+			return result, nil
+		}
+
+		if len(step.Mismatches) > 0 {
+			fmt.Fprintf(e.stderr, "==> Step %d didn't pass its assertion: %s\n", step.Index+1, strings.Join(step.Mismatches, ", "))
+		} else if step.Failed() {
+			fmt.Fprintf(e.stderr, "==> Step %d failed with exit code %d\n", step.Index+1, step.ExitCode)
+		}
+		if step.Failed() {
+			if !e.continueOnError {
+				// The session stays on the failed step.
+				return result, nil
+			}
+		}
+
+		if err := e.cl.NextCommand(); err != nil {
+			return result, fmt.Errorf("failed to move to the next step: %w", err)
+		}
+	}
+}
+
+func (e *Executor) confirmStep(state *State) (bool, error) {
+	if e.confirm == nil {
+		return false, nil
+	}
+	return e.confirm(state)
+}
+
+// shellState is where a step saves its working directory and exported variables for the next step.
+type shellState struct {
+	envFile string
+	dirFile string
+}
+
+// script wraps command so that it starts where the previous step left off and saves its own state on exit.
+func (ss *shellState) script(command string) string {
+	return fmt.Sprintf(`[ -f '%[1]s' ] && . '%[1]s' 2>/dev/null
+%[3]s
+__savvy_exit_code=$?
+pwd > '%[2]s'
+export -p > '%[1]s'
+exit $__savvy_exit_code
+`, ss.envFile, ss.dirFile, command)
+}
+
+func (ss *shellState) dir() string {
+	bs, err := os.ReadFile(ss.dirFile)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimRight(string(bs), "\r\n")
+}
+
+// runStep runs command and copies its stdout to output too. Steps are stopped once they run past the timeout of their
+// assertion.
+func (e *Executor) runStep(ctx context.Context, ss *shellState, index int, command string, assertion *Assertion, output io.Writer) (StepResult, error) {
+	step := StepResult{Index: index, Command: command}
+
+	stepCtx := ctx
+	if assertion != nil && assertion.Timeout > 0 {
+		var cancel context.CancelFunc
+		stepCtx, cancel = context.WithTimeout(ctx, assertion.Timeout)
+		defer cancel()
+	}
+
+	c := exec.CommandContext(stepCtx, e.shell, "-c", ss.script(command))
+	c.Dir = ss.dir()
+	c.Env = append(os.Environ(), e.env...)
+	c.Stdout = io.MultiWriter(e.stdout, output)
+	c.Stderr = e.stderr
+	// Steps run unattended, so they must not wait for input.
+	c.Stdin = nil
+	// commands the step started in the background may keep its output open after the step is stopped.
+	c.WaitDelay = time.Second
+
+	start := time.Now()
+	err := c.Run()
+	step.Duration = time.Since(start)
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		step.ExitCode = exitErr.ExitCode()
+		if step.ExitCode < 0 {
+			// the step was killed by a signal.
+			step.ExitCode = 1
+		}
+	default:
+		return step, fmt.Errorf("failed to run step %d: %w", index+1, err)
+	}
+	return step, nil
+}
@@ -0,0 +1,104 @@
+package run
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	savvy_client "github.com/getsavvyinc/savvy-cli/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecutor(t *testing.T) {
+	dir := t.TempDir()
+	rb := &savvy_client.Runbook{
+		Title: "test",
+		Steps: []savvy_client.Step{
+			{Command: "cd " + dir},
+			{Type: savvy_client.StepTypeNote, Description: "notes are skipped"},
+			{Command: "export GREETING=<greeting>"},
+			{Command: `echo "$GREETING from $(pwd)"`},
+			{Command: "exit 3"},
+			{Command: "echo done"},
+		},
+	}
+
+	testCases := []struct {
+		name            string
+		continueOnError bool
+		expectedOutput  string
+		expectedIndex   int
+		expectedSteps   int
+	}{
+		{
+			name:           "stops on error",
+			expectedOutput: "hello from " + dir + "\n",
+			expectedIndex:  3,
+			expectedSteps:  4,
+		},
+		{
+			name:            "continue on error",
+			continueOnError: true,
+			expectedOutput:  "hello from " + dir + "\ndone\n",
+			expectedIndex:   5,
+			expectedSteps:   5,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, cl, cleanup := newTestServerWithClient(t, rb)
+			t.Cleanup(func() { cleanup() })
+			assert.NoError(t, cl.SetParams(map[string]string{"<greeting>": "hello"}))
+
+			var stdout, stderr bytes.Buffer
+			e := NewExecutor(cl, WithOutput(&stdout, &stderr), WithContinueOnError(tc.continueOnError))
+			result, err := e.Run(context.Background())
+			assert.NoError(t, err)
+
+			assert.Equal(t, tc.expectedOutput, stdout.String())
+			assert.Len(t, result.Steps, tc.expectedSteps)
+			assert.Equal(t, 3, result.ExitCode())
+			assert.True(t, strings.Contains(stderr.String(), "Step 4 failed with exit code 3"))
+
+			// the session reflects the steps that ran.
+			st, err := cl.CurrentState()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedIndex, st.Index)
+		})
+	}
+
+	t.Run("interactive step", func(t *testing.T) {
+		rb := &savvy_client.Runbook{
+			Steps: []savvy_client.Step{
+				{Type: savvy_client.StepTypeInteractive, Command: "cat", SubSteps: []string{"select 1;", "select 2;"}},
+			},
+		}
+		_, cl, cleanup := newTestServerWithClient(t, rb)
+		t.Cleanup(func() { cleanup() })
+
+		var stdout bytes.Buffer
+		result, err := NewExecutor(cl, WithOutput(&stdout, &bytes.Buffer{})).Run(context.Background())
+		assert.NoError(t, err)
+		assert.Zero(t, result.ExitCode())
+		assert.Equal(t, "select 1;\nselect 2;\n", stdout.String())
+	})
+
+	t.Run("capture", func(t *testing.T) {
+		rb := &savvy_client.Runbook{
+			Steps: []savvy_client.Step{
+				{Command: "printf 'created\\nnamespace/test-42\\n' # savvy:capture namespace=namespace/(.+)"},
+				{Command: "echo <namespace>"},
+			},
+		}
+		_, cl, cleanup := newTestServerWithClient(t, rb)
+		t.Cleanup(func() { cleanup() })
+
+		var stdout bytes.Buffer
+		result, err := NewExecutor(cl, WithOutput(&stdout, &bytes.Buffer{})).Run(context.Background())
+		assert.NoError(t, err)
+		assert.Zero(t, result.ExitCode())
+		assert.Equal(t, "created\nnamespace/test-42\ntest-42\n", stdout.String())
+	})
+}
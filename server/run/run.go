@@ -9,12 +9,16 @@ import (
 	"net"
 	"os"
 	"strings"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	savvy_client "github.com/getsavvyinc/savvy-cli/client"
+	"github.com/getsavvyinc/savvy-cli/danger"
+	"github.com/getsavvyinc/savvy-cli/param"
+	"github.com/getsavvyinc/savvy-cli/server"
 	"github.com/getsavvyinc/savvy-cli/server/cleanup"
 	"github.com/getsavvyinc/savvy-cli/server/mode"
-	"github.com/getsavvyinc/savvy-cli/slice"
 )
 
 type RunServer struct {
@@ -26,18 +30,77 @@ type RunServer struct {
 	commands  []*RunCommand
 	params    map[string]string
 
+	// mu guards the output of the running step, which is written by the pty while connections are handled.
+	mu sync.Mutex
+	// running is the index of the step whose output is being captured, or -1.
+	running int
+	output  tailBuffer
+
+	// saved is where the state of the run is saved, so that it can be resumed.
+	saved *SavedRun
+	// secrets are the keys of the secret params, which aren't saved or logged.
+	secrets map[string]bool
+
+	audit *AuditLog
+	// classifier flags the steps that must be confirmed before they run.
+	classifier *danger.Classifier
+	// executed is the step entry of the command that is running, until it finishes.
+	executed *AuditEntry
+	// executedOutput is the output of the command that is running, if the audit log includes output.
+	executedOutput tailBuffer
+	// asserted is the step with an assertion that is running, until the command that runs it finishes.
+	asserted *assertedStep
+	// mismatch is set if the command that finished last ran a step that didn't pass its assertion.
+	mismatch *Mismatch
+
 	closed atomic.Bool
 }
 
 type RunCommand struct {
-	Command string            `json:"command,omitempty"`
-	Params  map[string]string `json:"params,omitempty"`
+	Command     string            `json:"command,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Params      map[string]string `json:"params,omitempty"`
+	// Index is the step to go to, or the step whose Output is reported by a step that ran outside the pty of the run
+	// session.
+	Index  int    `json:"index,omitempty"`
+	Output string `json:"output,omitempty"`
+	// Executed is a command that started running, and ExitCode is the exit code of the command that finished.
+	Executed string `json:"executed,omitempty"`
+	ExitCode int    `json:"exit_code,omitempty"`
+	// Capture is set if the output of the step sets a param.
+	Capture *Capture `json:"-"`
+	// Assertion is set if the step is expected to exit with a given exit code, print some output or finish in time.
+	Assertion *Assertion `json:"-"`
+
+	// confirmed is the dangerous command, with its params set, that the user confirmed.
+	confirmed string
+	assert    *savvy_client.Assertion
 }
 
 type State struct {
 	Command string            `json:"command"`
 	Index   int               `json:"index"`
 	Params  map[string]string `json:"params"`
+	// Danger is the reason the command is flagged as dangerous. Dangerous commands must be confirmed before they run.
+	Danger    string `json:"danger,omitempty"`
+	Confirmed bool   `json:"confirmed,omitempty"`
+	// Assert is the assertion of the command.
+	Assert *savvy_client.Assertion `json:"assert,omitempty"`
+	// Mismatch is set if the command that finished last ran a step that didn't pass its assertion.
+	Mismatch *Mismatch `json:"mismatch,omitempty"`
+}
+
+// NeedsConfirmation reports whether the command is dangerous and wasn't confirmed yet.
+func (s *State) NeedsConfirmation() bool {
+	return s.Danger != "" && !s.Confirmed
+}
+
+// Step describes a step of the run session.
+type Step struct {
+	Index       int    `json:"index"`
+	Command     string `json:"command"`
+	Description string `json:"description,omitempty"`
+	Danger      string `json:"danger,omitempty"`
 }
 
 func (s *State) CommandWithSetParams() string {
@@ -45,15 +108,24 @@ func (s *State) CommandWithSetParams() string {
 		return s.Command
 	}
 
-	cmd := s.Command
-	for k, v := range s.Params {
-		cmd = strings.ReplaceAll(cmd, k, v)
-	}
-	return cmd
+	return param.Replace(s.Command, s.Params)
 }
 
 const DefaultRunSocketPath = "/tmp/savvy-run.sock"
 
+// SocketPathEnv is the environment variable that holds the socket path of the current run session.
+// It is set in the environment of the shell spawned by savvy run.
+const SocketPathEnv = "SAVVY_RUN_SOCKET_PATH"
+
+// SocketPathFromEnv returns the socket path of the current run session.
+// It falls back to DefaultRunSocketPath if the current shell isn't part of a run session.
+func SocketPathFromEnv() string {
+	if socketPath := os.Getenv(SocketPathEnv); socketPath != "" {
+		return socketPath
+	}
+	return DefaultRunSocketPath
+}
+
 var ErrStartingRunSession = errors.New("failed to start run session")
 
 type Option func(s *RunServer)
@@ -64,6 +136,28 @@ func WithLogger(logger *slog.Logger) Option {
 	}
 }
 
+// WithSavedRun saves the state of the run whenever it changes.
+// If the run was saved before, the run resumes from the saved step with the saved params.
+func WithSavedRun(saved *SavedRun) Option {
+	return func(s *RunServer) {
+		s.saved = saved
+	}
+}
+
+// WithAuditLog logs the commands that run during the run session to the audit log.
+func WithAuditLog(audit *AuditLog) Option {
+	return func(s *RunServer) {
+		s.audit = audit
+	}
+}
+
+// WithClassifier flags dangerous steps, which must be confirmed before they run.
+func WithClassifier(classifier *danger.Classifier) Option {
+	return func(s *RunServer) {
+		s.classifier = classifier
+	}
+}
+
 // cleanupSocket is an internal function.
 // It is the callers responsibility to ensure the socketPath exists.
 func cleanupSocket(socketPath string) error {
@@ -83,6 +177,16 @@ func NewServerWithDefaultSocketPath(rb *savvy_client.Runbook, opts ...Option) (*
 	return NewServerWithSocketPath(DefaultRunSocketPath, rb, opts...)
 }
 
+// NewServerWithSessionSocketPath creates a run server that listens on a socket unique to this run session.
+// This allows multiple runbooks to run concurrently.
+func NewServerWithSessionSocketPath(rb *savvy_client.Runbook, opts ...Option) (*RunServer, error) {
+	socketPath, err := server.NewSessionSocketPath(mode.Run)
+	if err != nil {
+		return nil, err
+	}
+	return NewServerWithSocketPath(socketPath, rb, opts...)
+}
+
 func NewServerWithSocketPath(socketPath string, rb *savvy_client.Runbook, opts ...Option) (*RunServer, error) {
 	return newRunServer(socketPath, rb, opts...)
 }
@@ -92,6 +196,24 @@ var defaultLogger = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions
 var ErrAbortRun = errors.New("abort running runbook")
 
 func newRunServer(socketPath string, rb *savvy_client.Runbook, opts ...Option) (*RunServer, error) {
+	steps := rb.RunnableSteps()
+	cmds := make([]*RunCommand, 0, len(steps))
+	for i, step := range steps {
+		assertion, err := ParseAssertion(step.Assert)
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %w", i+1, err)
+		}
+
+		command, capture := parseCapture(step.Runnable())
+		cmds = append(cmds, &RunCommand{
+			Command:     command,
+			Description: step.Description,
+			Capture:     capture,
+			Assertion:   assertion,
+			assert:      step.Assert,
+		})
+	}
+
 	if fileInfo, _ := os.Stat(socketPath); fileInfo != nil {
 
 		cleanupOK, cerr := cleanup.GetPermission(mode.Run)
@@ -110,28 +232,80 @@ func newRunServer(socketPath string, rb *savvy_client.Runbook, opts ...Option) (
 		return nil, fmt.Errorf("failed to create listener: %w", err)
 	}
 
-	steps := rb.Steps
-
-	cmds := slice.Map(steps, func(step savvy_client.Step) *RunCommand {
-		return &RunCommand{
-			Command: step.Command,
-		}
-	})
-
 	rs := &RunServer{
 		socketPath: socketPath,
 		logger:     defaultLogger,
 		commands:   cmds,
 		listener:   listener,
 		params:     make(map[string]string),
+		running:    -1,
 	}
 
 	for _, opt := range opts {
 		opt(rs)
 	}
+
+	rs.secrets = make(map[string]bool)
+	for _, cmd := range rs.commands {
+		for _, p := range param.Parse(cmd.Command) {
+			if p.Secret {
+				rs.secrets[p.Key()] = true
+			}
+		}
+	}
+
+	if rs.saved != nil {
+		rs.restore(rb)
+	}
 	return rs, nil
 }
 
+// restore resumes the saved run.
+func (rs *RunServer) restore(rb *savvy_client.Runbook) {
+	// steps may have been removed since the run was saved.
+	rs.currIndex = max(0, min(rs.saved.Index, len(rs.commands)))
+	for k, v := range rs.saved.Params {
+		rs.params[k] = v
+	}
+
+	// the run continues with the runbook as it is now.
+	rs.saved.Runbook = rb
+	rs.saved.RunbookHash = runbookHash(rb)
+	rs.saved.Steps = len(rs.commands)
+	rs.saved.SocketPath = rs.socketPath
+	if rs.saved.Index != 0 || len(rs.saved.Params) > 0 {
+		// mark the run as running, so that it can't be resumed twice.
+		rs.persist()
+	}
+}
+
+// persist saves the state of the run. Finished runs are removed, since there is nothing left to resume.
+func (rs *RunServer) persist() {
+	if rs.saved == nil {
+		return
+	}
+
+	if rs.currIndex >= len(rs.commands) {
+		if err := rs.saved.Remove(); err != nil {
+			rs.logger.Debug("failed to remove saved run", "error", err.Error())
+		}
+		return
+	}
+
+	params := make(map[string]string, len(rs.params))
+	for k, v := range rs.params {
+		if !rs.secrets[k] {
+			params[k] = v
+		}
+	}
+	rs.saved.Index = rs.currIndex
+	rs.saved.Params = params
+	rs.saved.UpdatedAt = time.Now()
+	if err := rs.saved.Save(); err != nil {
+		rs.logger.Debug("failed to save run", "error", err.Error())
+	}
+}
+
 func (rs *RunServer) Close() error {
 	if rs.closed.Load() {
 		return nil
@@ -177,25 +351,63 @@ func (rs *RunServer) handleCommand(runCommand RunCommand, c net.Conn) {
 	case shutdownCommand:
 		rs.Close()
 	case nextCommand:
+		rs.startCapture(rs.currIndex)
 		rs.currIndex += 1
 		// NOTE: we intentionally allow currIndex to = len(rs.commands) that's how we know we're done
 		if rs.currIndex > len(rs.commands) {
 			rs.currIndex = len(rs.commands)
 		}
+		rs.persist()
+	case skipCommand:
+		// the skipped step didn't run, so there is no output to capture.
+		rs.startCapture(-1)
+		rs.currIndex = min(rs.currIndex+1, len(rs.commands))
+		rs.persist()
+	case gotoCommand:
+		rs.startCapture(-1)
+		rs.currIndex = max(0, min(runCommand.Index, len(rs.commands)))
+		rs.persist()
+	case listCommand:
+		steps := make([]Step, 0, len(rs.commands))
+		for i, cmd := range rs.commands {
+			command := param.Replace(cmd.Command, rs.params)
+			reason, _ := rs.classifier.Classify(command)
+			steps = append(steps, Step{
+				Index:       i,
+				Command:     command,
+				Description: cmd.Description,
+				Danger:      reason,
+			})
+		}
+		json.NewEncoder(c).Encode(steps)
 	case previousCommand:
+		rs.startCapture(-1)
 		rs.currIndex -= 1
 		if rs.currIndex < 0 {
 			rs.currIndex = 0
 		}
+		rs.persist()
 	case currentCommand:
+		if rs.captureOutput() {
+			rs.persist()
+		}
 		response := State{
-			Index:  rs.currIndex,
-			Params: rs.params,
+			Index:    rs.currIndex,
+			Params:   rs.params,
+			Mismatch: rs.mismatch,
 		}
 		if rs.currIndex < len(rs.commands) {
 			cmd := rs.commands[rs.currIndex]
 			response.Command = cmd.Command
+			response.Assert = cmd.assert
 			rs.logger.Debug("fetching command", "command", cmd)
+
+			// params can make a step dangerous, so the step is classified with its params set.
+			command := param.Replace(cmd.Command, rs.params)
+			if reason, ok := rs.classifier.Classify(command); ok {
+				response.Danger = reason
+				response.Confirmed = cmd.confirmed == command
+			}
 		}
 		json.NewEncoder(c).Encode(response)
 	case paramCommand:
@@ -205,11 +417,226 @@ func (rs *RunServer) handleCommand(runCommand RunCommand, c net.Conn) {
 				rs.params[k] = v
 			}
 		}
+		rs.persist()
+	case overrideParamCommand:
+		// params set explicitly e.g with --param override the params of the resumed run.
+		for k, v := range runCommand.Params {
+			rs.params[k] = v
+		}
+		rs.persist()
+	case confirmCommand:
+		if runCommand.Index >= 0 && runCommand.Index < len(rs.commands) {
+			cmd := rs.commands[runCommand.Index]
+			cmd.confirmed = param.Replace(cmd.Command, rs.params)
+		}
+	case startedCommand:
+		rs.startExecuted(runCommand.Executed)
+	case finishedCommand:
+		rs.finishExecuted(runCommand.ExitCode, runCommand.Output)
+	case outputCommand:
+		if runCommand.Index >= 0 && runCommand.Index < len(rs.commands) {
+			if rs.capture(rs.commands[runCommand.Index], server.CleanOutput(runCommand.Output)) {
+				rs.persist()
+			}
+		}
 	default:
 		rs.logger.Debug("unknown command", "command", cmd)
 	}
 }
 
+// Write records the output of the run session, so that the output of a step can set a param.
+func (rs *RunServer) Write(p []byte) (int, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.executed != nil && rs.audit.withOutput {
+		rs.executedOutput.Write(p)
+	}
+	if rs.asserted != nil {
+		rs.asserted.output.Write(p)
+	}
+	if rs.running < 0 {
+		return len(p), nil
+	}
+	return rs.output.Write(p)
+}
+
+// startExecuted starts the audit log entry of a command that runs while the current step is current, and records
+// its output if it runs a step with an assertion.
+func (rs *RunServer) startExecuted(command string) {
+	rs.startAsserted(command)
+	if rs.audit == nil {
+		return
+	}
+
+	entry := &AuditEntry{
+		Type:       AuditStep,
+		Time:       time.Now(),
+		StepNumber: rs.currIndex + 1,
+		Command:    command,
+		Match:      MatchEdited,
+	}
+	if rs.currIndex < len(rs.commands) {
+		step := rs.commands[rs.currIndex].Command
+		entry.Step = param.Replace(step, rs.params)
+		if entry.Step == command {
+			entry.Match = MatchMatched
+		}
+
+		for _, p := range param.Parse(step) {
+			value, ok := rs.params[p.Key()]
+			if !ok {
+				continue
+			}
+			if rs.secrets[p.Key()] {
+				value = redactedValue
+			}
+			if entry.Params == nil {
+				entry.Params = make(map[string]string)
+			}
+			entry.Params[p.Key()] = value
+		}
+	} else {
+		// commands that run after the last step aren't part of the runbook.
+		entry.StepNumber = 0
+		entry.Match = ""
+	}
+	entry.Command = rs.redactSecrets(entry.Command)
+	entry.Step = rs.redactSecrets(entry.Step)
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.executed = entry
+	rs.executedOutput.Reset()
+}
+
+// startAsserted records the output of command if it runs the current step and the step has an assertion.
+// Commands that differ from the step aren't checked, since they may do something else entirely.
+func (rs *RunServer) startAsserted(command string) {
+	var asserted *assertedStep
+	if rs.currIndex < len(rs.commands) {
+		cmd := rs.commands[rs.currIndex]
+		if cmd.Assertion != nil && param.Replace(cmd.Command, rs.params) == command {
+			asserted = &assertedStep{index: rs.currIndex, started: time.Now()}
+		}
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.asserted = asserted
+}
+
+// redactSecrets replaces the values of secret params in s.
+func (rs *RunServer) redactSecrets(s string) string {
+	for key := range rs.secrets {
+		if value := rs.params[key]; value != "" {
+			s = strings.ReplaceAll(s, value, redactedValue)
+		}
+	}
+	return s
+}
+
+// finishExecuted logs the command that started last. output is the output of commands that ran outside the pty of the
+// run session.
+func (rs *RunServer) finishExecuted(exitCode int, output string) {
+	rs.finishAsserted(exitCode, output)
+
+	rs.mu.Lock()
+	entry := rs.executed
+	rs.executed = nil
+	if output == "" {
+		output = rs.executedOutput.String()
+	}
+	rs.executedOutput.Reset()
+	rs.mu.Unlock()
+
+	if entry == nil {
+		return
+	}
+
+	finishedAt := time.Now()
+	entry.FinishedAt = &finishedAt
+	entry.ExitCode = &exitCode
+	if rs.audit.withOutput {
+		entry.Output = rs.redactSecrets(server.TruncateOutput(server.CleanOutput(output)))
+	}
+	if err := rs.audit.append(*entry); err != nil {
+		rs.logger.Debug("failed to write audit log", "error", err.Error())
+	}
+}
+
+// finishAsserted checks the assertion of the step that finished, if a step with an assertion was running.
+// Prompts that show up without a command running clear the mismatch of the step that finished before.
+func (rs *RunServer) finishAsserted(exitCode int, output string) {
+	rs.mu.Lock()
+	asserted := rs.asserted
+	rs.asserted = nil
+	if asserted != nil && output == "" {
+		output = asserted.output.String()
+	}
+	rs.mu.Unlock()
+
+	rs.mismatch = nil
+	if asserted == nil {
+		return
+	}
+
+	cmd := rs.commands[asserted.index]
+	mismatches := cmd.Assertion.Check(exitCode, server.CleanOutput(output), time.Since(asserted.started))
+	if len(mismatches) > 0 {
+		rs.mismatch = &Mismatch{Index: asserted.index, Mismatches: mismatches}
+	}
+}
+
+// startCapture starts recording the output of the step at index. Steps without a capture aren't recorded.
+func (rs *RunServer) startCapture(index int) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.output.Reset()
+	rs.running = -1
+	if index >= 0 && index < len(rs.commands) && rs.commands[index].Capture != nil {
+		rs.running = index
+	}
+}
+
+// captureOutput evaluates the capture of the running step on the output recorded so far.
+// It runs until the capture finds a value, since the output may still be in flight when the shell asks for the
+// next step.
+func (rs *RunServer) captureOutput() bool {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.running < 0 {
+		return false
+	}
+
+	output := rs.output.String()
+	// the last line is incomplete, it's usually the prompt of the shell.
+	idx := strings.LastIndexByte(output, '\n')
+	if idx < 0 {
+		return false
+	}
+
+	if !rs.capture(rs.commands[rs.running], server.CleanOutput(output[:idx])) {
+		return false
+	}
+	rs.running = -1
+	rs.output.Reset()
+	return true
+}
+
+// capture sets the param of the step's capture to the value found in output, overwriting any value set before.
+func (rs *RunServer) capture(cmd *RunCommand, output string) bool {
+	if cmd.Capture == nil {
+		return false
+	}
+	value, ok := cmd.Capture.find(output)
+	if !ok {
+		return false
+	}
+	rs.logger.Debug("captured param", "param", cmd.Capture.Param)
+	rs.params[cmd.Capture.Param] = value
+	return true
+}
+
 func (rs *RunServer) SocketPath() string {
 	return rs.socketPath
 }
@@ -219,11 +646,19 @@ func (rs *RunServer) Commands() []*RunCommand {
 }
 
 const (
-	shutdownCommand = "savvy shutdown"
-	nextCommand     = "savvy internal next"
-	previousCommand = "savvy internal previous"
-	currentCommand  = "savvy internal current"
-	paramCommand    = "savvy internal param"
+	shutdownCommand      = "savvy shutdown"
+	nextCommand          = "savvy internal next"
+	previousCommand      = "savvy internal previous"
+	currentCommand       = "savvy internal current"
+	paramCommand         = "savvy internal param"
+	overrideParamCommand = "savvy internal override-param"
+	outputCommand        = "savvy internal output"
+	skipCommand          = "savvy internal skip"
+	gotoCommand          = "savvy internal goto"
+	listCommand          = "savvy internal list"
+	startedCommand       = "savvy internal started"
+	finishedCommand      = "savvy internal finished"
+	confirmCommand       = "savvy internal confirm"
 )
 
 func (rc *RunCommand) IsShutdown() bool {
@@ -72,6 +72,77 @@ func TestRunServer(t *testing.T) {
 			assert.Equal(t, "idx_0", st.CommandWithSetParams())
 		})
 	})
+	t.Run("TestSkipCommand", func(t *testing.T) {
+		_, cl, cleanup := newTestServerWithClient(t, rb)
+		t.Cleanup(func() { cleanup() })
+
+		assert.NoError(t, cl.SkipCommand())
+		st, err := cl.CurrentState()
+		assert.NoError(t, err)
+		assert.Equal(t, 1, st.Index)
+		assert.Equal(t, "idx_1", st.CommandWithSetParams())
+
+		t.Run("TestSkipLastCommand", func(t *testing.T) {
+			assert.NoError(t, cl.GoToCommand(2))
+			assert.NoError(t, cl.SkipCommand())
+			st, err := cl.CurrentState()
+			assert.NoError(t, err)
+			assert.Equal(t, 3, st.Index)
+			assert.Empty(t, st.Command)
+
+			// skipping past the end of the run is a no-op.
+			assert.NoError(t, cl.SkipCommand())
+			st, err = cl.CurrentState()
+			assert.NoError(t, err)
+			assert.Equal(t, 3, st.Index)
+		})
+	})
+	t.Run("TestGoToCommand", func(t *testing.T) {
+		testCases := []struct {
+			name          string
+			index         int
+			expectedIndex int
+		}{
+			{name: "forwards", index: 2, expectedIndex: 2},
+			{name: "backwards", index: 0, expectedIndex: 0},
+			{name: "past the last step ends the run", index: 10, expectedIndex: 3},
+			{name: "before the first step", index: -1, expectedIndex: 0},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				_, cl, cleanup := newTestServerWithClient(t, rb)
+				t.Cleanup(func() { cleanup() })
+
+				assert.NoError(t, cl.NextCommand())
+				assert.NoError(t, cl.GoToCommand(tc.index))
+				st, err := cl.CurrentState()
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedIndex, st.Index)
+			})
+		}
+	})
+	t.Run("TestListCommands", func(t *testing.T) {
+		_, cl, cleanup := newTestServerWithClient(t, rb)
+		t.Cleanup(func() { cleanup() })
+
+		assert.NoError(t, cl.GoToCommand(2))
+		assert.NoError(t, cl.SetParams(map[string]string{"<param>": "value"}))
+
+		// the list doesn't depend on the current step.
+		steps, err := cl.ListCommands()
+		assert.NoError(t, err)
+		if assert.Len(t, steps, 3) {
+			for i, step := range steps {
+				assert.Equal(t, i, step.Index)
+				assert.Equal(t, rb.Steps[i].Command, step.Command)
+			}
+		}
+
+		st, err := cl.CurrentState()
+		assert.NoError(t, err)
+		assert.Equal(t, 2, st.Index)
+	})
 	t.Run("TestParam", func(t *testing.T) {
 		_, cl, cleanup := newTestServerWithClient(t, rb)
 		t.Cleanup(func() { cleanup() })
@@ -127,10 +198,10 @@ func TestRunServer(t *testing.T) {
 
 type cleanupFunc func() error
 
-func newTestServerWithClient(t *testing.T, rb *savvy_client.Runbook) (*RunServer, Client, cleanupFunc) {
+func newTestServerWithClient(t *testing.T, rb *savvy_client.Runbook, opts ...Option) (*RunServer, Client, cleanupFunc) {
 	socketPath := "/tmp/savvy-run-test-" + idgen.New("tst") + ".sock"
 
-	srv, err := NewServerWithSocketPath(socketPath, rb)
+	srv, err := NewServerWithSocketPath(socketPath, rb, opts...)
 	assert.Nil(t, err)
 	assert.NotNil(t, srv)
 	assert.Equal(t, socketPath, srv.SocketPath())
@@ -203,3 +274,29 @@ func TestCommandWithSetParams(t *testing.T) {
 		})
 	}
 }
+
+func TestRunbookWithNotes(t *testing.T) {
+	rb := &savvy_client.Runbook{
+		Steps: []savvy_client.Step{
+			{Type: savvy_client.StepTypeNote, Description: "Before you start, check the dashboard"},
+			{Type: savvy_client.StepTypeCode, Command: "echo one"},
+			{Type: savvy_client.StepTypeNote, Description: "Wait for the rollout"},
+			{Type: savvy_client.StepTypeCode, Command: "echo two"},
+		},
+	}
+
+	// the shell gets its steps from Commands, so they must match the steps of the run session.
+	assert.Equal(t, []string{"echo one", "echo two"}, rb.Commands())
+
+	srv, cl, cleanup := newTestServerWithClient(t, rb)
+	t.Cleanup(func() { cleanup() })
+	assert.Len(t, srv.Commands(), 2)
+
+	for i, command := range rb.Commands() {
+		st, err := cl.CurrentState()
+		assert.NoError(t, err)
+		assert.Equal(t, i, st.Index)
+		assert.Equal(t, command, st.Command)
+		assert.NoError(t, cl.NextCommand())
+	}
+}
@@ -0,0 +1,164 @@
+package run
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	savvy_client "github.com/getsavvyinc/savvy-cli/client"
+	"github.com/getsavvyinc/savvy-cli/config"
+	"github.com/getsavvyinc/savvy-cli/idgen"
+)
+
+// DefaultRunsDir is where the state of run sessions is saved.
+var DefaultRunsDir = filepath.Join(config.DefaultConfigDir, "runs")
+
+const (
+	runIDPrefix  = "run-"
+	savedRunExt  = ".json"
+	savedRunPerm = 0600
+)
+
+// SavedRun is the state of a run session, saved so that the run can be resumed after savvy or the terminal exits.
+// Runs are saved whenever their state changes and removed once they move past the last step.
+type SavedRun struct {
+	RunID string `json:"run_id"`
+	// Local is true if the runbook was run with savvy run --local.
+	Local     bool      `json:"local,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Runbook is the runbook as it was when the run started.
+	Runbook *savvy_client.Runbook `json:"runbook"`
+	// RunbookHash identifies the steps of the runbook, so that changes made since the run started can be detected.
+	RunbookHash string `json:"runbook_hash"`
+	Index       int    `json:"index"`
+	Steps       int    `json:"steps"`
+	// Params holds the params that were set, except secret ones.
+	Params map[string]string `json:"params,omitempty"`
+	// SocketPath is the socket of the run session that last saved the run.
+	SocketPath string `json:"socket_path,omitempty"`
+
+	path string
+}
+
+// NewSavedRun returns the saved state of a new run of rb. Nothing is written until the state of the run changes.
+func NewSavedRun(rb *savvy_client.Runbook, local bool) *SavedRun {
+	runID := idgen.New(runIDPrefix)
+	now := time.Now()
+	return &SavedRun{
+		RunID:       runID,
+		Local:       local,
+		StartedAt:   now,
+		UpdatedAt:   now,
+		Runbook:     rb,
+		RunbookHash: runbookHash(rb),
+		path:        filepath.Join(DefaultRunsDir, runID+savedRunExt),
+	}
+}
+
+// runbookHash hashes the commands of the runbook.
+func runbookHash(rb *savvy_client.Runbook) string {
+	h := sha256.New()
+	for _, command := range rb.Commands() {
+		h.Write([]byte(command))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// RunbookChanged reports whether the steps of rb differ from the steps of the runbook when the run started.
+func (r *SavedRun) RunbookChanged(rb *savvy_client.Runbook) bool {
+	return r.RunbookHash != runbookHash(rb)
+}
+
+// Save writes the state of the run to disk.
+func (r *SavedRun) Save() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0700); err != nil {
+		return fmt.Errorf("failed to create runs dir: %w", err)
+	}
+
+	bs, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	// write to a temp file first, so that a crash never leaves a partially written run behind.
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, bs, savedRunPerm); err != nil {
+		return fmt.Errorf("failed to save run: %w", err)
+	}
+	if err := os.Rename(tmp, r.path); err != nil {
+		return fmt.Errorf("failed to save run: %w", err)
+	}
+	return nil
+}
+
+// Remove deletes the saved state of the run.
+func (r *SavedRun) Remove() error {
+	if err := os.Remove(r.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// UnfinishedRuns returns the runs that stopped before the last step and are no longer running.
+// The most recently updated run is returned first.
+func UnfinishedRuns() ([]*SavedRun, error) {
+	return unfinishedRuns(DefaultRunsDir)
+}
+
+func unfinishedRuns(dir string) ([]*SavedRun, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, runIDPrefix+"*"+savedRunExt))
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []*SavedRun
+	for _, path := range paths {
+		bs, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		var r SavedRun
+		if err := json.Unmarshal(bs, &r); err != nil || r.Runbook == nil {
+			continue
+		}
+		if r.SocketPath != "" && isRunAlive(r.SocketPath) {
+			continue
+		}
+		r.path = path
+		runs = append(runs, &r)
+	}
+
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].UpdatedAt.After(runs[j].UpdatedAt)
+	})
+	return runs, nil
+}
+
+func isRunAlive(socketPath string) bool {
+	conn, err := net.DialTimeout("unix", socketPath, 100*time.Millisecond)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// Label describes the run on a single line e.g "Migrate the db (step 3/40, 2024-05-01 10:00:00)".
+func (r *SavedRun) Label() string {
+	title := strings.TrimSpace(r.Runbook.Title)
+	if title == "" {
+		title = r.Runbook.RunbookID
+	}
+	return fmt.Sprintf("%s (step %d/%d, %s)", title, r.Index+1, r.Steps, r.UpdatedAt.Local().Format(time.DateTime))
+}
@@ -0,0 +1,86 @@
+package run
+
+import (
+	"testing"
+
+	savvy_client "github.com/getsavvyinc/savvy-cli/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSavedRun(t *testing.T) {
+	defaultRunsDir := DefaultRunsDir
+	DefaultRunsDir = t.TempDir()
+	t.Cleanup(func() { DefaultRunsDir = defaultRunsDir })
+
+	rb := &savvy_client.Runbook{
+		RunbookID: "rb-test",
+		Title:     "test",
+		Steps: []savvy_client.Step{
+			{Command: "psql -h <host>"},
+			{Command: "export TOKEN=<token!>"},
+			{Command: "echo done"},
+		},
+	}
+
+	saved := NewSavedRun(rb, false)
+	srv, cl, _ := newTestServerWithClient(t, rb, WithSavedRun(saved))
+
+	runs, err := UnfinishedRuns()
+	assert.NoError(t, err)
+	assert.Empty(t, runs, "runs are only saved once their state changes")
+
+	assert.NoError(t, cl.SetParams(map[string]string{"<host>": "db", "<token>": "secret"}))
+	assert.NoError(t, cl.NextCommand())
+	assert.NoError(t, cl.NextCommand())
+	// wait for the server to handle the commands.
+	_, err = cl.CurrentState()
+	assert.NoError(t, err)
+
+	runs, err = UnfinishedRuns()
+	assert.NoError(t, err)
+	assert.Empty(t, runs, "runs that are still running can't be resumed")
+
+	srv.Close()
+	runs, err = UnfinishedRuns()
+	assert.NoError(t, err)
+	if !assert.Len(t, runs, 1) {
+		return
+	}
+	resumed := runs[0]
+	assert.Equal(t, saved.RunID, resumed.RunID)
+	assert.Equal(t, 2, resumed.Index)
+	assert.Equal(t, map[string]string{"<host>": "db"}, resumed.Params, "secret params aren't saved")
+	assert.False(t, resumed.RunbookChanged(rb))
+
+	t.Run("resume", func(t *testing.T) {
+		_, cl, cleanup := newTestServerWithClient(t, rb, WithSavedRun(resumed))
+		t.Cleanup(func() { cleanup() })
+
+		st, err := cl.CurrentState()
+		assert.NoError(t, err)
+		assert.Equal(t, 2, st.Index)
+		assert.Equal(t, "db", st.Params["<host>"])
+
+		// params passed to the resumed run override the saved params, unlike params set while it runs.
+		assert.NoError(t, cl.OverrideParams(map[string]string{"<host>": "replica"}))
+		assert.NoError(t, cl.SetParams(map[string]string{"<host>": "primary"}))
+		st, err = cl.CurrentState()
+		assert.NoError(t, err)
+		assert.Equal(t, "replica", st.Params["<host>"])
+
+		// finished runs can't be resumed.
+		assert.NoError(t, cl.NextCommand())
+		_, err = cl.CurrentState()
+		assert.NoError(t, err)
+		cleanup()
+		runs, err := UnfinishedRuns()
+		assert.NoError(t, err)
+		assert.Empty(t, runs)
+	})
+
+	t.Run("runbook changed", func(t *testing.T) {
+		changed := *rb
+		changed.Steps = append([]savvy_client.Step{{Command: "ls"}}, rb.Steps...)
+		assert.True(t, resumed.RunbookChanged(&changed))
+	})
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/getsavvyinc/savvy-cli/idgen"
+	"github.com/getsavvyinc/savvy-cli/server/mode"
+)
+
+// SocketPathEnv is the environment variable that holds the socket path of the current recording session.
+// It is set in the environment of the shell spawned by savvy record.
+const SocketPathEnv = "SAVVY_SOCKET_PATH"
+
+// TerminalEnv is the environment variable that holds the label of a terminal that joined a recording session.
+// It is set by savvy record join.
+const TerminalEnv = "SAVVY_TERMINAL"
+
+const sessionSocketPrefix = "sock-"
+
+// SessionSocketDir returns the directory that holds the sockets of all savvy sessions.
+// It is $XDG_RUNTIME_DIR/savvy if XDG_RUNTIME_DIR is set, otherwise it falls back to the os temp dir.
+func SessionSocketDir() string {
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+	if runtimeDir == "" {
+		runtimeDir = os.TempDir()
+	}
+	return filepath.Join(runtimeDir, "savvy")
+}
+
+// NewSessionSocketPath returns a unique socket path for a new session.
+// The socket directory is created if it doesn't exist.
+func NewSessionSocketPath(m mode.Mode) (string, error) {
+	dir := SessionSocketDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create socket dir: %w", err)
+	}
+
+	name := fmt.Sprintf("%s-%s.sock", m, idgen.New(sessionSocketPrefix))
+	return filepath.Join(dir, name), nil
+}
+
+// SocketPathFromEnv returns the socket path of the current recording session.
+// It falls back to DefaultSocketPath if the current shell isn't part of a recording session.
+func SocketPathFromEnv() string {
+	if socketPath := os.Getenv(SocketPathEnv); socketPath != "" {
+		return socketPath
+	}
+	return DefaultSocketPath
+}
+
+// ActiveSessions returns the socket paths of the sessions in mode m that are accepting connections.
+func ActiveSessions(m mode.Mode) []string {
+	paths, _ := filepath.Glob(filepath.Join(SessionSocketDir(), fmt.Sprintf("%s-%s*.sock", m, sessionSocketPrefix)))
+	if m == mode.Record {
+		paths = append(paths, DefaultSocketPath)
+	}
+
+	var active []string
+	for _, path := range paths {
+		if isSessionAlive(path) {
+			active = append(active, path)
+		}
+	}
+	return active
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+)
+
+const (
+	statusCommand  = "savvy record status"
+	excludeCommand = "savvy record exclude"
+)
+
+// Status is a snapshot of a recording session.
+type Status struct {
+	Steps        []StepStatus `json:"steps"`
+	Paused       bool         `json:"paused,omitempty"`
+	IgnoreErrors bool         `json:"ignore_errors,omitempty"`
+}
+
+// StepStatus describes a recorded step and whether it will be exported.
+type StepStatus struct {
+	StepID    string    `json:"step_id"`
+	Command   string    `json:"command,omitempty"`
+	Note      string    `json:"note,omitempty"`
+	Terminal  string    `json:"terminal,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	// Finished is false while the command is running. ExitCode is only meaningful once the command finished.
+	Finished bool `json:"finished,omitempty"`
+	ExitCode int  `json:"exit_code,omitempty"`
+	// Excluded is true if the user excluded the step from the export.
+	Excluded bool `json:"excluded,omitempty"`
+}
+
+// Included reports whether the step will be exported when the session ends.
+func (s *Status) Included(step StepStatus) bool {
+	if step.Excluded {
+		return false
+	}
+	return !(s.IgnoreErrors && step.Finished && step.ExitCode != 0)
+}
+
+func (s *UnixSocketServer) status() *Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := &Status{
+		Paused:       s.paused,
+		IgnoreErrors: s.ignoreErrors,
+	}
+	for _, cmd := range s.commands {
+		step := StepStatus{
+			StepID:    cmd.StepID,
+			Command:   cmd.Command,
+			Note:      cmd.Note,
+			Terminal:  cmd.Terminal,
+			StartedAt: cmd.StartedAt,
+			Finished:  !cmd.FinishedAt.IsZero(),
+			ExitCode:  cmd.ExitCode,
+			Excluded:  cmd.Excluded,
+		}
+		// notes and files don't run.
+		if cmd.IsNote() || cmd.HasFileData() {
+			step.Finished = true
+		}
+		status.Steps = append(status.Steps, step)
+	}
+
+	sort.SliceStable(status.Steps, func(i, j int) bool {
+		return status.Steps[i].StartedAt.Before(status.Steps[j].StartedAt)
+	})
+	return status
+}
+
+func (s *UnixSocketServer) writeStatus(w io.Writer) {
+	if err := json.NewEncoder(w).Encode(s.status()); err != nil {
+		s.logger.Debug("failed to write status", "error", err.Error())
+	}
+}
+
+// setExcluded excludes the given steps from the export and includes all others.
+func (s *UnixSocketServer) setExcluded(stepIDs []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	excluded := make(map[string]bool, len(stepIDs))
+	for _, id := range stepIDs {
+		excluded[id] = true
+	}
+	for _, cmd := range s.commands {
+		cmd.Excluded = excluded[cmd.StepID]
+	}
+
+	if err := s.journal.append(journalEntry{Data: &RecordedData{Command: excludeCommand, ExcludedSteps: stepIDs}}); err != nil {
+		s.logger.Debug("failed to write journal", "error", err.Error())
+	}
+}
@@ -10,10 +10,14 @@ import (
 	"log/slog"
 	"net"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
+	"github.com/getsavvyinc/savvy-cli/envcontext"
 	"github.com/getsavvyinc/savvy-cli/idgen"
 	"github.com/getsavvyinc/savvy-cli/server/cleanup"
 	"github.com/getsavvyinc/savvy-cli/server/mode"
@@ -30,6 +34,35 @@ type UnixSocketServer struct {
 	lookupCommand       map[string]*RecordedData
 	commandRecordedHook func(string)
 
+	// outputs holds the pty output captured for each step, keyed by step ID.
+	outputs map[string]*stepOutput
+	// currentStepID is the step that pty output is currently attributed to.
+	// It is empty between steps i.e after a step finishes and before the next one starts.
+	currentStepID string
+
+	// repl records the lines the user enters in a repl e.g psql that the current step started.
+	repl *replSession
+
+	// allowFile reports whether the contents of a file may be recorded.
+	allowFile func(path string) bool
+
+	// baselines holds the content of files when savvy record file first recorded them in the session, keyed by absolute path.
+	// Files that change afterwards are recorded as a diff against their baseline.
+	baselines map[string][]byte
+
+	// ignorePolicy decides which commands are left out of the recording.
+	ignorePolicy *IgnorePolicy
+
+	// dropContext is true if the environment snapshots sent with commands should not be recorded.
+	dropContext bool
+
+	// journal is an optional append-only log of the recorded data.
+	journal *Journal
+
+	// paused is true while the user has paused the recording session.
+	// Commands received while paused are not recorded.
+	paused bool
+
 	closed atomic.Bool
 }
 
@@ -64,7 +97,7 @@ func WithLogger(logger *slog.Logger) Option {
 // cleanupSocket is an internal function.
 // It is the callers responsibility to ensure the socketPath exists.
 func cleanupSocket(socketPath string) error {
-	cl, err := NewDefaultClient(context.Background())
+	cl, err := NewClient(context.Background(), socketPath)
 	if err != nil {
 		return err
 	}
@@ -76,6 +109,36 @@ func cleanupSocket(socketPath string) error {
 	return nil
 }
 
+// WithFileFilter only records the contents of files for which allow returns true.
+// allow is called with the absolute path of the file.
+func WithFileFilter(allow func(path string) bool) Option {
+	return func(s *UnixSocketServer) {
+		s.allowFile = allow
+	}
+}
+
+// WithIgnorePolicy leaves the commands ignored by policy out of the recording.
+func WithIgnorePolicy(policy *IgnorePolicy) Option {
+	return func(s *UnixSocketServer) {
+		s.ignorePolicy = policy
+	}
+}
+
+// WithJournal writes every recorded step to the journal so that the session can be recovered after a crash.
+func WithJournal(journal *Journal) Option {
+	return func(s *UnixSocketServer) {
+		s.journal = journal
+	}
+}
+
+// WithContextSnapshots records the environment e.g git branch or kube context each command ran against.
+// It is enabled by default.
+func WithContextSnapshots(enabled bool) Option {
+	return func(s *UnixSocketServer) {
+		s.dropContext = !enabled
+	}
+}
+
 func WithIgnoreErrors(ignoreErrors bool) Option {
 	return func(s *UnixSocketServer) {
 		s.ignoreErrors = ignoreErrors
@@ -88,6 +151,16 @@ func NewUnixSocketServerWithDefaultPath(opts ...Option) (*UnixSocketServer, erro
 	return NewUnixSocketServer(DefaultSocketPath, opts...)
 }
 
+// NewUnixSocketServerWithSessionPath creates a server that listens on a socket unique to this recording session.
+// This allows multiple recording sessions to run concurrently.
+func NewUnixSocketServerWithSessionPath(opts ...Option) (*UnixSocketServer, error) {
+	socketPath, err := NewSessionSocketPath(mode.Record)
+	if err != nil {
+		return nil, err
+	}
+	return NewUnixSocketServer(socketPath, opts...)
+}
+
 func NewUnixSocketServer(socketPath string, opts ...Option) (*UnixSocketServer, error) {
 	return newUnixSocketServer(socketPath, opts...)
 }
@@ -120,25 +193,56 @@ func newUnixSocketServer(socketPath string, opts ...Option) (*UnixSocketServer,
 		logger:        defaultLogger,
 		ignoreErrors:  false,
 		lookupCommand: make(map[string]*RecordedData),
+		outputs:       make(map[string]*stepOutput),
+		baselines:     make(map[string][]byte),
 	}
 
 	for _, opt := range opts {
 		opt(srv)
 	}
 
+	if err := srv.journal.append(journalEntry{SocketPath: socketPath, StartedAt: time.Now(), IgnoreErrors: srv.ignoreErrors}); err != nil {
+		srv.logger.Debug("failed to write journal", "error", err.Error())
+	}
+
 	return srv, nil
 }
 
 type RecordedCommand struct {
-	Command  string    `json:"command"`
-	Prompt   string    `json:"prompt,omitempty"`
-	FileInfo *FileInfo `json:"file_info,omitempty"`
+	Command    string        `json:"command"`
+	Prompt     string        `json:"prompt,omitempty"`
+	Output     string        `json:"output,omitempty"`
+	StartedAt  time.Time     `json:"started_at"`
+	Duration   time.Duration `json:"duration,omitempty"`
+	WorkingDir string        `json:"working_dir,omitempty"`
+	FileInfo   *FileInfo     `json:"file_info,omitempty"`
+	// Context is the environment the command ran against. It is nil if it is unknown.
+	Context *envcontext.Snapshot `json:"context,omitempty"`
+	// Terminal is the label of the terminal that joined the session and ran the command.
+	// It is empty for the terminal that started the recording session.
+	Terminal string `json:"terminal,omitempty"`
+	// Note is a markdown note the user added between commands. Note steps have no command.
+	Note string `json:"note,omitempty"`
+	// SubSteps are the lines the user entered in an interactive session e.g psql or python that the command started.
+	SubSteps []string `json:"sub_steps,omitempty"`
+}
+
+// IsNote reports whether the step is a note rather than a command or file.
+func (rc *RecordedCommand) IsNote() bool {
+	return rc.Note != ""
 }
 
 type FileInfo struct {
 	Path    string      `json:"path,omitempty"`
 	Mode    fs.FileMode `json:"mode,omitempty"`
 	Content []byte      `json:"content,omitempty"`
+	// Type describes Content: the file itself, a tarball of a directory or a diff.
+	Type FileType `json:"type,omitempty"`
+}
+
+// IsText reports whether Content is text that can be shown to and edited by the user.
+func (fi *FileInfo) IsText() bool {
+	return fi.Type != FileTypeDirectory
 }
 
 func (s *UnixSocketServer) Commands() []*RecordedCommand {
@@ -152,35 +256,100 @@ func (s *UnixSocketServer) Commands() []*RecordedCommand {
 			continue
 		}
 
+		if cmd.Excluded {
+			continue
+		}
+
+		if cmd.IsNote() {
+			commands = append(commands, &RecordedCommand{
+				Note:       cmd.Note,
+				StartedAt:  cmd.StartedAt,
+				WorkingDir: cmd.WorkingDir,
+				Terminal:   cmd.Terminal,
+			})
+			continue
+		}
+
 		if cmd.HasFileData() {
 			recordedFile := &RecordedCommand{
-				Command: cmd.Command,
+				Command:    cmd.Command,
+				StartedAt:  cmd.StartedAt,
+				WorkingDir: cmd.WorkingDir,
+				Terminal:   cmd.Terminal,
 				FileInfo: &FileInfo{
 					Path:    cmd.Filepath,
 					Mode:    cmd.FileMode,
 					Content: cmd.FileData,
+					Type:    cmd.FileType,
 				},
 			}
 			commands = append(commands, recordedFile)
 			continue
 		}
 
-		commands = append(commands, &RecordedCommand{
-			Command: cmd.Command,
-			Prompt:  cmd.Prompt,
-		})
+		rc := &RecordedCommand{
+			Command:    cmd.Command,
+			Prompt:     cmd.Prompt,
+			StartedAt:  cmd.StartedAt,
+			Duration:   cmd.Duration(),
+			WorkingDir: cmd.WorkingDir,
+			Context:    cmd.Context,
+			Terminal:   cmd.Terminal,
+			SubSteps:   cmd.SubSteps,
+		}
+		if output, ok := s.outputs[cmd.StepID]; ok {
+			rc.Output = output.String()
+		}
+		commands = append(commands, rc)
 	}
+
+	// Steps from different terminals may arrive out of order, so order them by when they started.
+	sort.SliceStable(commands, func(i, j int) bool {
+		return commands[i].StartedAt.Before(commands[j].StartedAt)
+	})
 	return commands
 }
 
+// Write attributes pty output to the step that is currently executing.
+// Output written while no step is executing (e.g the shell prompt) is discarded.
+//
+// Write never returns an error so that it is safe to use with io.MultiWriter.
+func (s *UnixSocketServer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.currentStepID == "" {
+		return len(p), nil
+	}
+
+	output, ok := s.outputs[s.currentStepID]
+	if !ok {
+		output = &stepOutput{}
+		s.outputs[s.currentStepID] = output
+	}
+	output.Write(p)
+	if s.repl != nil && s.repl.stepID == s.currentStepID {
+		s.repl.output(p)
+	}
+	return len(p), nil
+}
+
 func (s *UnixSocketServer) Close() error {
 	if s.listener != nil {
 		s.closed.Store(true)
+		os.Remove(PausedIndicatorPath(s.socketPath))
+		s.journal.Close()
 		return s.listener.Close()
 	}
 	return nil
 }
 
+// PausedIndicatorPath returns the path of the file that exists while the recording session at socketPath is paused.
+// The shell hooks check for this file to update the prompt.
+func PausedIndicatorPath(socketPath string) string {
+	return socketPath + ".paused"
+}
+
 func (s *UnixSocketServer) ListenAndServe() {
 	for {
 		// Accept new connections
@@ -206,18 +375,85 @@ type RecordedData struct {
 	Filepath string      `json:"filepath,omitempty"`
 	FileData []byte      `json:"file_data,omitempty"`
 	FileMode fs.FileMode `json:"file_mode,omitempty"`
+	FileType FileType    `json:"file_type,omitempty"`
+	Note     string      `json:"note,omitempty"`
+
+	WorkingDir string    `json:"working_dir,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+
+	// Context is the environment the command ran against e.g the git branch or kube context.
+	Context *envcontext.Snapshot `json:"context,omitempty"`
+
+	// UndoCount is the number of steps to remove. It is only set for undo control messages.
+	UndoCount int `json:"undo_count,omitempty"`
+
+	// LeadingSpace is true if the command was typed with a leading space.
+	LeadingSpace bool `json:"leading_space,omitempty"`
+
+	// Excluded is true if the user excluded the step from the export with savvy record status.
+	Excluded bool `json:"excluded,omitempty"`
+	// ExcludedSteps are the IDs of the steps to exclude. It is only set for exclude control messages.
+	ExcludedSteps []string `json:"excluded_steps,omitempty"`
+
+	// Terminal labels the terminal that joined the session with savvy record join.
+	// It is empty for the terminal that started the recording session.
+	Terminal string `json:"terminal,omitempty"`
+
+	// SubSteps are the lines the user entered in an interactive session e.g psql that the command started.
+	// They are only set in the journal, so that a recovered session keeps them.
+	SubSteps []string `json:"sub_steps,omitempty"`
+}
+
+// Duration returns how long the command ran for.
+// It returns 0 if the command hasn't finished or the start time is unknown.
+func (rd *RecordedData) Duration() time.Duration {
+	if rd.StartedAt.IsZero() || rd.FinishedAt.IsZero() || rd.FinishedAt.Before(rd.StartedAt) {
+		return 0
+	}
+	return rd.FinishedAt.Sub(rd.StartedAt)
 }
 
 func (rd *RecordedData) HasFileData() bool {
 	return strings.HasPrefix(rd.StepID, idgen.FilePrefix)
 }
 
-const shutdownCommand = "savvy shutdown"
+func (rd *RecordedData) IsNote() bool {
+	return strings.HasPrefix(rd.StepID, idgen.NotePrefix)
+}
+
+const (
+	shutdownCommand = "savvy shutdown"
+	pauseCommand    = "savvy record pause"
+	resumeCommand   = "savvy record resume"
+	undoCommand     = "savvy record undo"
+	noteCommand     = "savvy record note"
+	stopCommand     = "savvy record stop"
+)
+
+// controlCommandPrefixes are savvy commands that control the recording session.
+// They are never recorded as steps.
+var controlCommandPrefixes = []string{
+	"savvy record file",
+	pauseCommand,
+	resumeCommand,
+	undoCommand,
+	noteCommand,
+	stopCommand,
+	statusCommand,
+	excludeCommand,
+}
 
 func (rd *RecordedData) IsShutdown() bool {
 	return rd.Command == shutdownCommand
 }
 
+// IsStepFinished reports whether the data marks the end of a previously recorded step.
+// The shell hooks send the step ID and exit code, without a command, once a command finishes.
+func (rd *RecordedData) IsStepFinished() bool {
+	return strings.TrimSpace(rd.Command) == "" && rd.StepID != ""
+}
+
 func (s *UnixSocketServer) handleConnection(c net.Conn) {
 	defer c.Close()
 
@@ -233,8 +469,11 @@ func (s *UnixSocketServer) handleConnection(c net.Conn) {
 		return
 	}
 
-	if data.IsShutdown() {
-		s.Close()
+	// Control messages are sent by savvy commands and have no step ID.
+	// The shell hooks also send the control commands the user types e.g savvy record undo, but with a step ID.
+	// Those must not be acted on twice.
+	if data.StepID == "" {
+		s.handleControl(c, data)
 		return
 	}
 
@@ -243,13 +482,41 @@ func (s *UnixSocketServer) handleConnection(c net.Conn) {
 		return
 	}
 
+	if data.IsNote() {
+		s.recordNote(data)
+		return
+	}
+
+	if data.IsStepFinished() {
+		// Sub-steps are recorded by the server from the input of the repl, never by the shell hooks.
+		data.SubSteps = nil
+		s.finishStep(data)
+		return
+	}
+
 	if s.maybeAppendData(data) && s.commandRecordedHook != nil {
 		s.commandRecordedHook(data.Command)
 	}
+}
 
-	if data.StepID != "" && data.ExitCode != 0 {
-		s.logger.Debug("command failed", "command", data.Command, "exit_status", data.ExitCode)
-		s.updateExitStatus(data.StepID, data.ExitCode)
+// handleControl acts on messages that control the recording session.
+func (s *UnixSocketServer) handleControl(c net.Conn, data RecordedData) {
+	switch data.Command {
+	case shutdownCommand:
+		s.Close()
+	case pauseCommand:
+		s.setPaused(true)
+	case resumeCommand:
+		s.setPaused(false)
+	case undoCommand:
+		removed := s.undo(data.UndoCount)
+		if err := json.NewEncoder(c).Encode(undoResponse{Removed: removed}); err != nil {
+			s.logger.Debug("failed to write undo response", "error", err.Error())
+		}
+	case statusCommand:
+		s.writeStatus(c)
+	case excludeCommand:
+		s.setExcluded(data.ExcludedSteps)
 	}
 }
 
@@ -257,12 +524,47 @@ func (s *UnixSocketServer) SocketPath() string {
 	return s.socketPath
 }
 
-func (s *UnixSocketServer) updateExitStatus(stepID string, exitCode int) {
+// finishStep records the exit status of the step and stops attributing pty output to it.
+func (s *UnixSocketServer) finishStep(data RecordedData) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if cmd, ok := s.lookupCommand[stepID]; ok {
-		cmd.ExitCode = exitCode
+	if s.currentStepID == data.StepID {
+		s.currentStepID = ""
+	}
+
+	// data only holds sub-steps when the journal is replayed.
+	subSteps := data.SubSteps
+	if s.repl != nil && s.repl.stepID == data.StepID {
+		subSteps = s.repl.finish()
+		s.repl = nil
+	}
+
+	cmd, ok := s.lookupCommand[data.StepID]
+	if !ok {
+		return
+	}
+
+	if data.ExitCode != 0 {
+		s.logger.Debug("command failed", "command", cmd.Command, "exit_status", data.ExitCode)
+	}
+	cmd.ExitCode = data.ExitCode
+	cmd.FinishedAt = data.FinishedAt
+	if cmd.FinishedAt.IsZero() {
+		cmd.FinishedAt = time.Now()
+	}
+	if len(subSteps) > 0 {
+		cmd.SubSteps = subSteps
+	}
+
+	entry := journalEntry{
+		Data: &RecordedData{StepID: cmd.StepID, ExitCode: cmd.ExitCode, FinishedAt: cmd.FinishedAt, SubSteps: cmd.SubSteps},
+	}
+	if output, ok := s.outputs[cmd.StepID]; ok {
+		entry.Output = output.String()
+	}
+	if err := s.journal.append(entry); err != nil {
+		s.logger.Debug("failed to write journal", "error", err.Error())
 	}
 }
 
@@ -276,8 +578,26 @@ func (s *UnixSocketServer) maybeAppendData(data RecordedData) bool {
 		return false
 	}
 
-	// do not record ignore savvy record file commands
-	if strings.HasPrefix(cmd, "savvy record file") {
+	// A new command started, so any output that follows doesn't belong to the previous step.
+	// Output is only captured for the terminal savvy record started, not for terminals that joined the session.
+	if data.Terminal == "" {
+		s.currentStepID = ""
+		s.repl = nil
+	}
+
+	if s.paused {
+		return false
+	}
+
+	// do not record savvy commands that control the recording session
+	for _, prefix := range controlCommandPrefixes {
+		if strings.HasPrefix(cmd, prefix) {
+			return false
+		}
+	}
+
+	if s.ignorePolicy.Ignores(data) {
+		s.logger.Debug("command ignored", "command", data.Command)
 		return false
 	}
 
@@ -285,51 +605,175 @@ func (s *UnixSocketServer) maybeAppendData(data RecordedData) bool {
 		return false
 	}
 
-	s.commands = append(s.commands, &data)
-	s.lookupCommand[data.StepID] = &data
-	s.logger.Debug("command recorded", "command", data.Command)
+	if data.StartedAt.IsZero() {
+		data.StartedAt = time.Now()
+	}
+
+	if s.dropContext {
+		data.Context = nil
+	}
+
+	// Only keep the last of consecutive identical commands e.g retries of a failing command.
+	if len(s.commands) > 0 && s.ignorePolicy.collapses(s.commands[len(s.commands)-1], data) {
+		s.removeLastSteps(1)
+	}
+
+	s.record(&data)
+	if data.Terminal == "" {
+		s.currentStepID = data.StepID
+		if r := detectREPL(cmd); r != nil {
+			s.repl = newREPLSession(data.StepID, r)
+		}
+	}
+	s.logger.Debug("command recorded", "command", data.Command, "terminal", data.Terminal)
 	return true
 }
 
-func (s *UnixSocketServer) recordFile(data RecordedData) {
-	filePath := data.Filepath
+// record appends data to the recorded steps and the journal.
+// The caller must hold s.mu.
+func (s *UnixSocketServer) record(data *RecordedData) {
+	s.commands = append(s.commands, data)
+	s.lookupCommand[data.StepID] = data
 
-	if err := checkFile(filePath); err != nil {
-		s.logger.Debug("file checks failed", "error", err.Error())
+	if err := s.journal.append(journalEntry{Data: data}); err != nil {
+		s.logger.Debug("failed to write journal", "error", err.Error())
+	}
+}
+
+func (s *UnixSocketServer) setPaused(paused bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.paused = paused
+	s.currentStepID = ""
+
+	indicator := PausedIndicatorPath(s.socketPath)
+	if !paused {
+		os.Remove(indicator)
+		s.logger.Debug("recording resumed")
 		return
 	}
 
-	fd, err := os.Open(filePath)
-	if err != nil {
-		s.logger.Debug("failed to open file", "error", err.Error())
+	if err := os.WriteFile(indicator, nil, 0600); err != nil {
+		s.logger.Debug("failed to create paused indicator", "error", err.Error())
+	}
+	s.logger.Debug("recording paused")
+}
+
+// undo removes the last n recorded steps.
+// undo removes the last n recorded steps and returns how many were removed.
+func (s *UnixSocketServer) undo(n int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if n <= 0 {
+		n = 1
+	}
+	return s.removeLastSteps(n)
+}
+
+// removeLastSteps removes the last n recorded steps, journals their removal and returns how many were removed.
+// The caller must hold s.mu.
+func (s *UnixSocketServer) removeLastSteps(n int) int {
+	if n > len(s.commands) {
+		n = len(s.commands)
+	}
+
+	removed := s.commands[len(s.commands)-n:]
+	s.commands = s.commands[:len(s.commands)-n]
+
+	for _, cmd := range removed {
+		delete(s.lookupCommand, cmd.StepID)
+		delete(s.outputs, cmd.StepID)
+		if s.currentStepID == cmd.StepID {
+			s.currentStepID = ""
+		}
+		s.logger.Debug("step removed", "command", cmd.Command)
+	}
+
+	if err := s.journal.append(journalEntry{Data: &RecordedData{Command: undoCommand, UndoCount: n}}); err != nil {
+		s.logger.Debug("failed to write journal", "error", err.Error())
+	}
+	return n
+}
+
+// recordNote inserts a note step after the steps recorded so far.
+func (s *UnixSocketServer) recordNote(data RecordedData) {
+	if strings.TrimSpace(data.Note) == "" {
 		return
 	}
-	defer fd.Close()
 
-	bs, err := io.ReadAll(fd)
-	if err != nil {
-		s.logger.Debug("failed to read file", "error", err.Error())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.paused {
 		return
 	}
 
-	fi, err := fd.Stat()
-	if err != nil {
-		s.logger.Debug("failed to get file info", "error", err.Error())
+	if _, ok := s.lookupCommand[data.StepID]; ok {
 		return
 	}
 
-	data.FileData = bs
-	data.Filepath = filePath
-	data.FileMode = fi.Mode()
+	if data.StartedAt.IsZero() {
+		data.StartedAt = time.Now()
+	}
+
+	s.record(&data)
+	s.logger.Debug("note recorded", "note", data.Note)
+}
+
+// diffName returns the path of the file at filePath relative to dir, or its base name if it isn't inside dir.
+func diffName(filePath, dir string) string {
+	if dir != "" {
+		if rel, err := filepath.Rel(dir, filePath); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return filepath.Base(filePath)
+}
+
+func (s *UnixSocketServer) recordFile(data RecordedData) {
+	filePath := data.Filepath
+	// The file path is relative to the directory savvy record file was run in.
+	if !filepath.IsAbs(filePath) && data.WorkingDir != "" {
+		filePath = filepath.Join(data.WorkingDir, filePath)
+	}
+
+	if s.allowFile != nil && !s.allowFile(filePath) {
+		s.logger.Debug("file excluded by redaction rules", "file", filePath)
+		return
+	}
+
+	if err := CheckFile(filePath); err != nil {
+		s.logger.Debug("file checks failed", "error", err.Error())
+		return
+	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if s.paused {
+		return
+	}
+
 	if _, ok := s.lookupCommand[data.StepID]; ok {
 		return
 	}
 
-	s.commands = append(s.commands, &data)
-	s.lookupCommand[data.StepID] = &data
-	s.logger.Debug("file recorded", "file", data.Filepath)
+	if data.StartedAt.IsZero() {
+		data.StartedAt = time.Now()
+	}
+
+	content, fileMode, fileType, err := s.fileContent(filePath, diffName(filePath, data.WorkingDir))
+	if err != nil {
+		s.logger.Debug("failed to read file", "error", err.Error())
+		return
+	}
+
+	data.FileData = content
+	data.FileMode = fileMode
+	data.FileType = fileType
+
+	s.record(&data)
+	s.logger.Debug("file recorded", "file", data.Filepath, "type", data.FileType)
 }
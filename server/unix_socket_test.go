@@ -0,0 +1,321 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/getsavvyinc/savvy-cli/archive"
+	"github.com/getsavvyinc/savvy-cli/envcontext"
+	"github.com/getsavvyinc/savvy-cli/idgen"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestServer(t *testing.T) *UnixSocketServer {
+	socketPath := "/tmp/savvy-record-test-" + idgen.New("tst") + ".sock"
+
+	srv, err := NewUnixSocketServer(socketPath)
+	assert.NoError(t, err)
+	assert.NotNil(t, srv)
+	t.Cleanup(func() { srv.Close() })
+	return srv
+}
+
+func recordCommand(srv *UnixSocketServer, command string) string {
+	stepID := idgen.New(idgen.CommandPrefix)
+	srv.maybeAppendData(RecordedData{Command: command, StepID: stepID})
+	return stepID
+}
+
+func TestUnixSocketServer(t *testing.T) {
+	t.Run("TestOutputIsAttributedToCurrentStep", func(t *testing.T) {
+		srv := newTestServer(t)
+
+		srv.Write([]byte("prompt> "))
+		stepID := recordCommand(srv, "echo hello")
+		srv.Write([]byte("hello\r\n"))
+		srv.finishStep(RecordedData{StepID: stepID, ExitCode: 1})
+		srv.Write([]byte("prompt> "))
+
+		cmds := srv.Commands()
+		assert.Len(t, cmds, 1)
+		assert.Equal(t, "echo hello", cmds[0].Command)
+		assert.Equal(t, "hello", cmds[0].Output)
+	})
+
+	t.Run("TestPromptAndTiming", func(t *testing.T) {
+		srv := newTestServer(t)
+
+		startedAt := time.Unix(1700000000, 250000000)
+		stepID := idgen.New(idgen.CommandPrefix)
+		srv.maybeAppendData(RecordedData{Command: "make build", StepID: stepID, Prompt: "~/app (main) $ ", StartedAt: startedAt, WorkingDir: "/app"})
+		srv.finishStep(RecordedData{StepID: stepID, FinishedAt: startedAt.Add(1500 * time.Millisecond)})
+
+		cmds := srv.Commands()
+		assert.Len(t, cmds, 1)
+		assert.Equal(t, "~/app (main) $ ", cmds[0].Prompt)
+		assert.Equal(t, "/app", cmds[0].WorkingDir)
+		assert.True(t, startedAt.Equal(cmds[0].StartedAt))
+		assert.Equal(t, 1500*time.Millisecond, cmds[0].Duration)
+	})
+
+	t.Run("TestPauseAndResume", func(t *testing.T) {
+		srv := newTestServer(t)
+
+		recordCommand(srv, "echo one")
+		srv.setPaused(true)
+		_, err := os.Stat(PausedIndicatorPath(srv.SocketPath()))
+		assert.NoError(t, err)
+
+		recordCommand(srv, "echo secret")
+		srv.setPaused(false)
+		_, err = os.Stat(PausedIndicatorPath(srv.SocketPath()))
+		assert.True(t, os.IsNotExist(err))
+
+		recordCommand(srv, "echo two")
+
+		cmds := srv.Commands()
+		assert.Len(t, cmds, 2)
+		assert.Equal(t, "echo one", cmds[0].Command)
+		assert.Equal(t, "echo two", cmds[1].Command)
+	})
+
+	t.Run("TestUndo", func(t *testing.T) {
+		srv := newTestServer(t)
+
+		recordCommand(srv, "echo one")
+		recordCommand(srv, "echo two")
+		recordCommand(srv, "echo three")
+		recordCommand(srv, "savvy record undo 2")
+
+		assert.Equal(t, 2, srv.undo(2))
+		cmds := srv.Commands()
+		assert.Len(t, cmds, 1)
+		assert.Equal(t, "echo one", cmds[0].Command)
+
+		// only the steps that were recorded are removed.
+		assert.Equal(t, 1, srv.undo(5))
+		assert.Empty(t, srv.Commands())
+		assert.Equal(t, 0, srv.undo(1))
+	})
+
+	t.Run("TestUndoMessages", func(t *testing.T) {
+		testCases := []struct {
+			name          string
+			stepID        string
+			expectedSteps int
+		}{
+			// savvy record undo sends the undo without a step ID.
+			{name: "without step ID", expectedSteps: 2},
+			// the shell hooks send the typed savvy record undo with a step ID. It's not an undo of its own.
+			{name: "with step ID", stepID: idgen.New(idgen.CommandPrefix), expectedSteps: 3},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				srv := newTestServer(t)
+				go srv.ListenAndServe()
+
+				recordCommand(srv, "echo one")
+				recordCommand(srv, "echo two")
+				recordCommand(srv, "echo three")
+
+				cl, err := NewClient(context.Background(), srv.SocketPath())
+				assert.NoError(t, err)
+				assert.NoError(t, cl.(*client).send(RecordedData{Command: undoCommand, StepID: tc.stepID, UndoCount: 1}))
+
+				assert.Eventually(t, func() bool {
+					return len(srv.Commands()) == tc.expectedSteps
+				}, time.Second, 10*time.Millisecond)
+				assert.Never(t, func() bool { return len(srv.Commands()) != tc.expectedSteps }, 100*time.Millisecond, 10*time.Millisecond)
+			})
+		}
+	})
+
+	t.Run("TestNote", func(t *testing.T) {
+		srv := newTestServer(t)
+
+		recordCommand(srv, "kubectl get pods")
+		recordCommand(srv, "savvy record note pods are healthy")
+		srv.recordNote(RecordedData{Command: noteCommand, StepID: idgen.New(idgen.NotePrefix), Note: "pods are healthy"})
+		recordCommand(srv, "kubectl rollout restart deploy/api")
+
+		cmds := srv.Commands()
+		assert.Len(t, cmds, 3)
+		assert.True(t, cmds[1].IsNote())
+		assert.Equal(t, "pods are healthy", cmds[1].Note)
+		assert.Empty(t, cmds[1].Command)
+	})
+
+	t.Run("TestFileChangedDuringSessionIsRecordedAsPatch", func(t *testing.T) {
+		srv := newTestServer(t)
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.yaml")
+		assert.NoError(t, os.WriteFile(path, []byte("replicas: 1\nport: 8080\n"), 0644))
+
+		// the file is recorded in full before it is edited.
+		srv.recordFile(RecordedData{Command: "savvy record file app.yaml", Filepath: "app.yaml", WorkingDir: dir, StepID: idgen.New(idgen.FilePrefix)})
+		recordCommand(srv, "vim app.yaml")
+		assert.NoError(t, os.WriteFile(path, []byte("replicas: 3\nport: 8080\n"), 0644))
+
+		srv.recordFile(RecordedData{Command: "savvy record file app.yaml", Filepath: "app.yaml", WorkingDir: dir, StepID: idgen.New(idgen.FilePrefix)})
+
+		cmds := srv.Commands()
+		assert.Len(t, cmds, 3)
+		assert.Equal(t, FileTypeFile, cmds[0].FileInfo.Type)
+		fileInfo := cmds[2].FileInfo
+		assert.Equal(t, FileTypePatch, fileInfo.Type)
+		assert.Contains(t, string(fileInfo.Content), "-replicas: 1\n+replicas: 3\n")
+	})
+
+	t.Run("TestNewFileIsRecordedInFull", func(t *testing.T) {
+		srv := newTestServer(t)
+		dir := t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "app.yaml"), []byte("port: 8080\n"), 0644))
+
+		srv.recordFile(RecordedData{Command: "savvy record file app.yaml", Filepath: "app.yaml", WorkingDir: dir, StepID: idgen.New(idgen.FilePrefix)})
+
+		cmds := srv.Commands()
+		assert.Len(t, cmds, 1)
+		assert.Equal(t, FileTypeFile, cmds[0].FileInfo.Type)
+		assert.Equal(t, "port: 8080\n", string(cmds[0].FileInfo.Content))
+	})
+
+	t.Run("TestDirectory", func(t *testing.T) {
+		srv := newTestServer(t)
+		dir := filepath.Join(t.TempDir(), "config")
+		assert.NoError(t, os.MkdirAll(dir, 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "app.yaml"), []byte("port: 8080\n"), 0644))
+
+		srv.recordFile(RecordedData{Command: "savvy record file " + dir, Filepath: dir, StepID: idgen.New(idgen.FilePrefix)})
+
+		cmds := srv.Commands()
+		assert.Len(t, cmds, 1)
+		assert.Equal(t, FileTypeDirectory, cmds[0].FileInfo.Type)
+		assert.False(t, cmds[0].FileInfo.IsText())
+	})
+
+	t.Run("TestRepoDirectoryWithLargeGitDir", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "repo")
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, ".git", "objects"), 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644))
+		// .git isn't part of the recording, so it doesn't count towards the limits.
+		for i := 0; i <= archive.MaxFiles; i++ {
+			assert.NoError(t, os.WriteFile(filepath.Join(dir, ".git", "objects", strconv.Itoa(i)), []byte("x"), 0644))
+		}
+
+		assert.NoError(t, CheckFile(dir))
+	})
+
+	t.Run("TestPatchOfNestedFile", func(t *testing.T) {
+		srv := newTestServer(t)
+		dir := t.TempDir()
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, "deploy"), 0755))
+		path := filepath.Join(dir, "deploy", "app.yaml")
+		assert.NoError(t, os.WriteFile(path, []byte("replicas: 1\n"), 0644))
+
+		srv.recordFile(RecordedData{Command: "savvy record file deploy/app.yaml", Filepath: "deploy/app.yaml", WorkingDir: dir, StepID: idgen.New(idgen.FilePrefix)})
+		assert.NoError(t, os.WriteFile(path, []byte("replicas: 3\n"), 0644))
+		srv.recordFile(RecordedData{Command: "savvy record file deploy/app.yaml", Filepath: "deploy/app.yaml", WorkingDir: dir, StepID: idgen.New(idgen.FilePrefix)})
+
+		cmds := srv.Commands()
+		assert.Len(t, cmds, 2)
+		assert.Contains(t, string(cmds[1].FileInfo.Content), "--- a/deploy/app.yaml\n+++ b/deploy/app.yaml\n")
+	})
+
+	t.Run("TestBinaryFileIsNotRecorded", func(t *testing.T) {
+		srv := newTestServer(t)
+		path := filepath.Join(t.TempDir(), "app.bin")
+		assert.NoError(t, os.WriteFile(path, []byte{0x7f, 'E', 'L', 'F', 0, 0, 1}, 0644))
+
+		assert.ErrorIs(t, CheckFile(path), ErrBinaryFile)
+		srv.recordFile(RecordedData{Command: "savvy record file " + path, Filepath: path, StepID: idgen.New(idgen.FilePrefix)})
+		assert.Empty(t, srv.Commands())
+	})
+
+	t.Run("TestContextSnapshots", func(t *testing.T) {
+		srv := newTestServer(t)
+		snapshot := &envcontext.Snapshot{KubeContext: "prod", KubeNamespace: "api"}
+
+		srv.maybeAppendData(RecordedData{Command: "kubectl delete pod api-0", StepID: idgen.New(idgen.CommandPrefix), Context: snapshot})
+		cmds := srv.Commands()
+		assert.Len(t, cmds, 1)
+		assert.Equal(t, snapshot, cmds[0].Context)
+
+		WithContextSnapshots(false)(srv)
+		srv.maybeAppendData(RecordedData{Command: "kubectl delete pod api-1", StepID: idgen.New(idgen.CommandPrefix), Context: snapshot})
+		cmds = srv.Commands()
+		assert.Len(t, cmds, 2)
+		assert.Nil(t, cmds[1].Context)
+	})
+
+	t.Run("TestJoinedTerminal", func(t *testing.T) {
+		srv := newTestServer(t)
+		start := time.Now()
+
+		mainStep := idgen.New(idgen.CommandPrefix)
+		srv.maybeAppendData(RecordedData{Command: "tail -f app.log", StepID: mainStep, StartedAt: start})
+		// the joined terminal's command arrives late.
+		srv.maybeAppendData(RecordedData{Command: "systemctl restart app", StepID: idgen.New(idgen.CommandPrefix), StartedAt: start.Add(-time.Second), Terminal: "host:pts/2"})
+		srv.Write([]byte("restarted\r\n"))
+		srv.finishStep(RecordedData{StepID: mainStep})
+
+		cmds := srv.Commands()
+		assert.Len(t, cmds, 2)
+		assert.Equal(t, "systemctl restart app", cmds[0].Command)
+		assert.Equal(t, "host:pts/2", cmds[0].Terminal)
+		assert.Empty(t, cmds[0].Output)
+		// pty output belongs to the terminal savvy record started.
+		assert.Equal(t, "restarted", cmds[1].Output)
+	})
+
+	t.Run("TestStatusAndExclude", func(t *testing.T) {
+		srv := newTestServer(t)
+		go srv.ListenAndServe()
+
+		failed := recordCommand(srv, "make test")
+		srv.finishStep(RecordedData{StepID: failed, ExitCode: 2})
+		recordCommand(srv, "make deploy")
+
+		cl, err := NewClient(context.Background(), srv.SocketPath())
+		assert.NoError(t, err)
+
+		status, err := cl.Status()
+		assert.NoError(t, err)
+		assert.Len(t, status.Steps, 2)
+		assert.True(t, status.Steps[0].Finished)
+		assert.Equal(t, 2, status.Steps[0].ExitCode)
+		assert.False(t, status.Steps[1].Finished)
+
+		assert.NoError(t, cl.SendExcludedSteps([]string{failed}))
+		assert.Eventually(t, func() bool {
+			cmds := srv.Commands()
+			return len(cmds) == 1 && cmds[0].Command == "make deploy"
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("TestTypedControlCommandIsNotActedOn", func(t *testing.T) {
+		srv := newTestServer(t)
+		go srv.ListenAndServe()
+
+		recordCommand(srv, "echo one")
+		recordCommand(srv, "echo two")
+
+		// the shell hooks send the typed command, with a step ID, before savvy record undo sends the undo.
+		hook, err := NewClient(context.Background(), srv.SocketPath())
+		assert.NoError(t, err)
+		assert.NoError(t, hook.(*client).send(RecordedData{Command: undoCommand, StepID: idgen.New(idgen.CommandPrefix)}))
+		removed, err := hook.SendUndo(1)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, removed)
+
+		assert.Eventually(t, func() bool {
+			cmds := srv.Commands()
+			return len(cmds) == 1 && cmds[0].Command == "echo one"
+		}, time.Second, 10*time.Millisecond)
+		assert.Never(t, func() bool { return len(srv.Commands()) == 0 }, 100*time.Millisecond, 10*time.Millisecond)
+	})
+}
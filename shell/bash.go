@@ -13,10 +13,12 @@ import (
 	"time"
 
 	"github.com/getsavvyinc/savvy-cli/client"
+	"github.com/getsavvyinc/savvy-cli/server"
 	"github.com/getsavvyinc/savvy-cli/tail"
 )
 
 type bash struct {
+	tempPaths
 	shellCmd string
 	// Exported to use in template
 	SocketPath string
@@ -110,13 +112,14 @@ func (b *bash) Spawn(ctx context.Context) (*exec.Cmd, error) {
 		return nil, err
 	}
 	defer bashrc.Close()
+	b.track(bashrc.Name())
 
 	if err := bashTemplate.Execute(bashrc, b); err != nil {
 		return nil, err
 	}
 
 	cmd := exec.CommandContext(ctx, b.shellCmd, "--rcfile", bashrc.Name())
-	cmd.Env = append(os.Environ(), "SAVVY_CONTEXT=record")
+	cmd.Env = append(os.Environ(), "SAVVY_CONTEXT=record", server.SocketPathEnv+"="+b.SocketPath)
 	cmd.WaitDelay = 500 * time.Millisecond
 	return cmd, nil
 }
@@ -173,13 +176,14 @@ func (b *bash) SpawnHistoryExpander(ctx context.Context) (*exec.Cmd, error) {
 		return nil, err
 	}
 	defer bashrc.Close()
+	b.track(bashrc.Name())
 
 	if err := bashHistoryTemplate.Execute(bashrc, b); err != nil {
 		return nil, err
 	}
 
 	cmd := exec.CommandContext(ctx, b.shellCmd, "--rcfile", bashrc.Name())
-	cmd.Env = os.Environ()
+	cmd.Env = append(os.Environ(), server.SocketPathEnv+"="+b.SocketPath)
 	cmd.WaitDelay = 500 * time.Millisecond
 	return cmd, nil
 }
@@ -187,6 +191,7 @@ func (b *bash) SpawnHistoryExpander(ctx context.Context) (*exec.Cmd, error) {
 const bashRunSetup = `
 echo
 echo "Type 'ctrl+n' to get the next command."
+echo "Type 'ctrl+x s' to skip a step or 'ctrl+x g' to go to any step."
 echo
 echo "Type 'exit' or press 'ctrl+d' to stop recording."
 `
@@ -198,17 +203,26 @@ func (b *bash) SpawnRunbookRunner(ctx context.Context, runbook *client.Runbook)
 		return nil, err
 	}
 	defer bashrc.Close()
+	b.track(bashrc.Name())
 
 	if err := bashRunTemplate.Execute(bashrc, b); err != nil {
 		return nil, err
 	}
 
 	cmd := exec.CommandContext(ctx, b.shellCmd, "--rcfile", bashrc.Name())
-	cmd.Env = append(os.Environ(), runbookRunMetadata(runbook, b)...)
+	cmd.Env = append(os.Environ(), runbookRunMetadata(runbook, b, b.SocketPath)...)
 	cmd.WaitDelay = 500 * time.Millisecond
 	return cmd, nil
 }
 
+func (b *bash) RecordHereSnippet(journalPath string) (string, error) {
+	return posixRecordHereSnippet(b.SocketPath, journalPath), nil
+}
+
+func (b *bash) JoinSnippet(terminal string) (string, error) {
+	return posixJoinSnippet(b.SocketPath, terminal), nil
+}
+
 func (b *bash) DefaultStartingArrayIndex() int {
 	return 0
 }
@@ -13,12 +13,14 @@ import (
 	"time"
 
 	"github.com/getsavvyinc/savvy-cli/client"
+	"github.com/getsavvyinc/savvy-cli/server"
 	"github.com/getsavvyinc/savvy-cli/tail"
 )
 
 var _ Shell = (*fish)(nil)
 
 type fish struct {
+	tempPaths
 	shellCmd string
 	// Exported to use in template
 	SocketPath string
@@ -58,6 +60,7 @@ func (f *fish) injectVendorCode(vendorCode *template.Template) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	f.track(tmpDir)
 	fishVendorConfDir := filepath.Join(tmpDir, "fish", "vendor_conf.d")
 	if err := os.MkdirAll(fishVendorConfDir, 0755); err != nil {
 		return "", err
@@ -92,7 +95,7 @@ func (f *fish) Spawn(ctx context.Context) (*exec.Cmd, error) {
 	dataDirs := addVendorDirToXDGDataDirPath(vendorDir)
 
 	cmd := exec.CommandContext(ctx, f.shellCmd)
-	cmd.Env = append(os.Environ(), "SAVVY_CONTEXT=record", fmt.Sprintf("XDG_DATA_DIRS=%s", dataDirs))
+	cmd.Env = append(os.Environ(), "SAVVY_CONTEXT=record", fmt.Sprintf("XDG_DATA_DIRS=%s", dataDirs), server.SocketPathEnv+"="+f.SocketPath)
 	cmd.WaitDelay = 500 * time.Millisecond
 	return cmd, nil
 }
@@ -149,7 +152,7 @@ func (f *fish) SpawnHistoryExpander(ctx context.Context) (*exec.Cmd, error) {
 	dataDirs := addVendorDirToXDGDataDirPath(vendorDir)
 
 	cmd := exec.CommandContext(ctx, f.shellCmd)
-	cmd.Env = append(os.Environ(), fmt.Sprintf("XDG_DATA_DIRS=%s", dataDirs))
+	cmd.Env = append(os.Environ(), fmt.Sprintf("XDG_DATA_DIRS=%s", dataDirs), server.SocketPathEnv+"="+f.SocketPath)
 	cmd.WaitDelay = 500 * time.Millisecond
 	return cmd, nil
 }
@@ -163,11 +166,29 @@ func (f *fish) SpawnRunbookRunner(ctx context.Context, runbook *client.Runbook)
 
 	cmd := exec.CommandContext(ctx, f.shellCmd)
 	cmd.Env = append(os.Environ(), "SAVVY_CONTEXT=run", fmt.Sprintf("XDG_DATA_DIRS=%s", dataDirs))
-	cmd.Env = append(cmd.Env, runbookRunMetadata(runbook, f)...)
+	cmd.Env = append(cmd.Env, runbookRunMetadata(runbook, f, f.SocketPath)...)
 	cmd.WaitDelay = 500 * time.Millisecond
 	return cmd, nil
 }
 
+func (f *fish) RecordHereSnippet(journalPath string) (string, error) {
+	return fmt.Sprintf(`set -gx SAVVY_CONTEXT record
+set -gx %s %s
+set -gx %s %s
+set -gx %s 1
+set -g SAVVY_INPUT_FILE %s
+`, server.SocketPathEnv, fishQuote(f.SocketPath), server.JournalPathEnv, fishQuote(journalPath), RecordHereEnv, fishQuote(f.SocketPath)), nil
+}
+
+func (f *fish) JoinSnippet(terminal string) (string, error) {
+	return fmt.Sprintf(`set -gx SAVVY_CONTEXT record
+set -gx %s %s
+set -gx %s %s
+set -gx %s 1
+set -g SAVVY_INPUT_FILE %s
+`, server.SocketPathEnv, fishQuote(f.SocketPath), server.TerminalEnv, fishQuote(terminal), RecordHereEnv, fishQuote(f.SocketPath)), nil
+}
+
 func (f *fish) DefaultStartingArrayIndex() int {
 	return 1
 }
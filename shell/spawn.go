@@ -3,9 +3,13 @@ package shell
 import (
 	"context"
 	"errors"
+	"fmt"
+	"os"
 	"os/exec"
+	"strings"
 
 	"github.com/getsavvyinc/savvy-cli/client"
+	"github.com/getsavvyinc/savvy-cli/server"
 	"github.com/getsavvyinc/savvy-cli/shell/internal/detect"
 	"github.com/getsavvyinc/savvy-cli/shell/kind"
 )
@@ -16,6 +20,72 @@ type Shell interface {
 	SpawnHistoryExpander(ctx context.Context) (*exec.Cmd, error)
 	SpawnRunbookRunner(ctx context.Context, runbook *client.Runbook) (*exec.Cmd, error)
 	DefaultStartingArrayIndex() int
+	// RecordHereSnippet returns shell code that, when eval'd, records the commands of the current shell
+	// to the recording session at the shell's socket path.
+	RecordHereSnippet(journalPath string) (string, error)
+	// JoinSnippet returns shell code that, when eval'd, records the commands of the current shell
+	// to the running recording session at the shell's socket path. Steps are labeled with terminal.
+	JoinSnippet(terminal string) (string, error)
+	// Close removes the temporary config files written for the spawned shells.
+	// Call it once the spawned shell exits: subshells read them again on startup.
+	Close() error
+}
+
+// tempPaths tracks the temporary config files and directories written for spawned shells.
+type tempPaths struct {
+	paths []string
+}
+
+func (t *tempPaths) track(path string) {
+	t.paths = append(t.paths, path)
+}
+
+func (t *tempPaths) Close() error {
+	var errs []error
+	for _, p := range t.paths {
+		if err := os.RemoveAll(p); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	t.paths = nil
+	return errors.Join(errs...)
+}
+
+// RecordHereEnv is set in shells that record via savvy record --here.
+// The shell hooks use it to stop recording once the recording session is stopped.
+const RecordHereEnv = "SAVVY_RECORD_HERE"
+
+// shellQuote quotes s so that it is interpreted literally by posix shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote quotes s so that it is interpreted literally by fish.
+// Unlike posix shells, fish unescapes \\ and \' in single quoted strings.
+func fishQuote(s string) string {
+	return "'" + fishQuoteReplacer.Replace(s) + "'"
+}
+
+var fishQuoteReplacer = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+// posixRecordHereSnippet is the RecordHereSnippet for bash and zsh.
+func posixRecordHereSnippet(socketPath, journalPath string) string {
+	return fmt.Sprintf(`export SAVVY_CONTEXT=record
+export %s=%s
+export %s=%s
+export %s=1
+SAVVY_INPUT_FILE=%s
+`, server.SocketPathEnv, shellQuote(socketPath), server.JournalPathEnv, shellQuote(journalPath), RecordHereEnv, shellQuote(socketPath))
+}
+
+// posixJoinSnippet is the JoinSnippet for bash and zsh.
+func posixJoinSnippet(socketPath, terminal string) string {
+	return fmt.Sprintf(`export SAVVY_CONTEXT=record
+export %s=%s
+export %s=%s
+export %s=1
+SAVVY_INPUT_FILE=%s
+`, server.SocketPathEnv, shellQuote(socketPath), server.TerminalEnv, shellQuote(terminal), RecordHereEnv, shellQuote(socketPath))
 }
 
 func New(logTarget string) Shell {
@@ -65,3 +135,15 @@ func (t *todo) SpawnRunbookRunner(ctx context.Context, runbook *client.Runbook)
 func (t *todo) DefaultStartingArrayIndex() int {
 	return 0
 }
+
+func (t *todo) RecordHereSnippet(journalPath string) (string, error) {
+	return "", errors.New("savvy doesn't support your current shell")
+}
+
+func (t *todo) JoinSnippet(terminal string) (string, error) {
+	return "", errors.New("savvy doesn't support your current shell")
+}
+
+func (t *todo) Close() error {
+	return nil
+}
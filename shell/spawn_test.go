@@ -0,0 +1,144 @@
+package shell
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloseRemovesTempConfig(t *testing.T) {
+	shells := []Shell{
+		&zsh{shellCmd: "zsh", SocketPath: "/tmp/savvy.sock"},
+		&bash{shellCmd: "bash", SocketPath: "/tmp/savvy.sock"},
+		&fish{shellCmd: "fish", SocketPath: "/tmp/savvy.sock"},
+	}
+
+	for _, sh := range shells {
+		c, err := sh.SpawnHistoryExpander(context.Background())
+		assert.NoError(t, err)
+
+		paths := tempConfigPaths(c.Args, c.Env)
+		assert.NotEmpty(t, paths)
+		for _, p := range paths {
+			_, err := os.Stat(p)
+			assert.NoError(t, err)
+		}
+
+		assert.NoError(t, sh.Close())
+		for _, p := range paths {
+			_, err := os.Stat(p)
+			assert.True(t, os.IsNotExist(err), "%s wasn't removed", p)
+		}
+	}
+}
+
+// tempConfigPaths returns the temporary config files and directories a spawned shell reads.
+func tempConfigPaths(args, env []string) []string {
+	var paths []string
+	for _, arg := range args {
+		if strings.Contains(arg, "savvy-bashrc-") {
+			paths = append(paths, arg)
+		}
+	}
+	for _, kv := range env {
+		if dir, ok := strings.CutPrefix(kv, "ZDOTDIR="); ok {
+			paths = append(paths, dir)
+		}
+		if dirs, ok := strings.CutPrefix(kv, "XDG_DATA_DIRS="); ok {
+			for _, dir := range strings.Split(dirs, ":") {
+				if strings.Contains(dir, "savvy-fish-") {
+					paths = append(paths, dir)
+				}
+			}
+		}
+	}
+	return paths
+}
+
+func TestShellQuote(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		posix string
+		fish  string
+	}{
+		{name: "plain path", input: "/tmp/savvy.sock", posix: `'/tmp/savvy.sock'`, fish: `'/tmp/savvy.sock'`},
+		{name: "spaces and variables", input: "/tmp/my dir/$HOME", posix: `'/tmp/my dir/$HOME'`, fish: `'/tmp/my dir/$HOME'`},
+		{name: "single quote", input: "/tmp/it's/savvy.sock", posix: `'/tmp/it'\''s/savvy.sock'`, fish: `'/tmp/it\'s/savvy.sock'`},
+		{name: "backslashes", input: `/tmp/a\b\'`, posix: `'/tmp/a\b\'\'''`, fish: `'/tmp/a\\b\\\''`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.posix, shellQuote(tc.input))
+			assert.Equal(t, tc.fish, fishQuote(tc.input))
+
+			// the shells that are installed print the quoted string as is.
+			quoted := map[string]string{"bash": tc.posix, "zsh": tc.posix, "fish": tc.fish}
+			for sh, q := range quoted {
+				if _, err := exec.LookPath(sh); err != nil {
+					continue
+				}
+				out, err := exec.Command(sh, "-c", "printf '%s' "+q).Output()
+				assert.NoError(t, err)
+				assert.Equal(t, tc.input, string(out), sh)
+			}
+		})
+	}
+}
+
+func TestRecordHereSnippet(t *testing.T) {
+	socketPath := "/tmp/it's/savvy.sock"
+	journalPath := "/tmp/journal dir/savvy.journal"
+
+	posixSnippet := `export SAVVY_CONTEXT=record
+export SAVVY_SOCKET_PATH='/tmp/it'\''s/savvy.sock'
+export SAVVY_JOURNAL_PATH='/tmp/journal dir/savvy.journal'
+export SAVVY_RECORD_HERE=1
+SAVVY_INPUT_FILE='/tmp/it'\''s/savvy.sock'
+`
+
+	testCases := []struct {
+		name     string
+		sh       Shell
+		expected string
+	}{
+		{name: "bash", sh: &bash{shellCmd: "bash", SocketPath: socketPath}, expected: posixSnippet},
+		{name: "zsh", sh: &zsh{shellCmd: "zsh", SocketPath: socketPath}, expected: posixSnippet},
+		{
+			name: "fish",
+			sh:   &fish{shellCmd: "fish", SocketPath: socketPath},
+			expected: `set -gx SAVVY_CONTEXT record
+set -gx SAVVY_SOCKET_PATH '/tmp/it\'s/savvy.sock'
+set -gx SAVVY_JOURNAL_PATH '/tmp/journal dir/savvy.journal'
+set -gx SAVVY_RECORD_HERE 1
+set -g SAVVY_INPUT_FILE '/tmp/it\'s/savvy.sock'
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			snippet, err := tc.sh.RecordHereSnippet(journalPath)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, snippet)
+
+			if _, err := exec.LookPath(tc.name); err != nil {
+				return
+			}
+			// eval'ing the snippet sets the paths as is.
+			out, err := exec.Command(tc.name, "-c", snippet+`printf '%s\n%s\n%s' "$SAVVY_SOCKET_PATH" "$SAVVY_JOURNAL_PATH" "$SAVVY_INPUT_FILE"`).Output()
+			assert.NoError(t, err)
+			assert.Equal(t, socketPath+"\n"+journalPath+"\n"+socketPath, string(out))
+		})
+	}
+
+	t.Run("unsupported shell", func(t *testing.T) {
+		_, err := (&todo{}).RecordHereSnippet(journalPath)
+		assert.Error(t, err)
+	})
+}
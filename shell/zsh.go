@@ -15,12 +15,15 @@ import (
 	"time"
 
 	"github.com/getsavvyinc/savvy-cli/client"
+	"github.com/getsavvyinc/savvy-cli/server"
+	"github.com/getsavvyinc/savvy-cli/server/run"
 	"github.com/getsavvyinc/savvy-cli/tail"
 )
 
 const RunbookCommandDelimiter = "COMMA"
 
 type zsh struct {
+	tempPaths
 	shellCmd string
 	// Exported to use in template
 	SocketPath string
@@ -121,22 +124,14 @@ echo "Type 'exit' or press 'ctrl+d' to stop recording."
 
 func (z *zsh) Spawn(ctx context.Context) (*exec.Cmd, error) {
 	// Referenced: https://github.com/sbstp/kubie/blob/master/src/shell/zsh.rs
-	tmp := os.TempDir()
-	zshrcPath := filepath.Join(tmp, ".zshrc")
-	zshrc, err := os.Create(zshrcPath)
-	if err != nil {
-		return nil, err
-	}
-	defer zshrc.Close()
-
 	t := template.Must(template.New("zshrc").Parse(baseScript + recordScript))
-
-	if err := t.Execute(zshrc, z); err != nil {
+	tmp, err := z.writeZshrc(t)
+	if err != nil {
 		return nil, err
 	}
 
 	cmd := exec.CommandContext(ctx, z.shellCmd)
-	cmd.Env = append(os.Environ(), "ZDOTDIR="+tmp, "SAVVY_CONTEXT=record")
+	cmd.Env = append(os.Environ(), "ZDOTDIR="+tmp, "SAVVY_CONTEXT=record", server.SocketPathEnv+"="+z.SocketPath)
 	cmd.WaitDelay = 500 * time.Millisecond
 	return cmd, nil
 }
@@ -159,21 +154,14 @@ add-zsh-hook preexec __savvy_history_pre_exec__
 `
 
 func (z *zsh) SpawnHistoryExpander(ctx context.Context) (*exec.Cmd, error) {
-	tmp := os.TempDir()
-	zshrcPath := filepath.Join(tmp, ".zshrc")
-	zshrc, err := os.Create(zshrcPath)
-	if err != nil {
-		return nil, err
-	}
-	defer zshrc.Close()
-
 	t := template.Must(template.New("historyZshrc").Parse(baseScript + historyScript))
-	if err := t.Execute(zshrc, z); err != nil {
+	tmp, err := z.writeZshrc(t)
+	if err != nil {
 		return nil, err
 	}
 
 	cmd := exec.CommandContext(ctx, z.shellCmd)
-	cmd.Env = append(os.Environ(), "ZDOTDIR="+tmp)
+	cmd.Env = append(os.Environ(), "ZDOTDIR="+tmp, server.SocketPathEnv+"="+z.SocketPath)
 	cmd.WaitDelay = 500 * time.Millisecond
 	return cmd, nil
 }
@@ -256,27 +244,48 @@ echo
 `
 
 func (z *zsh) SpawnRunbookRunner(ctx context.Context, runbook *client.Runbook) (*exec.Cmd, error) {
-	tmp := os.TempDir()
-	zshrcPath := filepath.Join(tmp, ".zshrc")
-	zshrc, err := os.Create(zshrcPath)
-	if err != nil {
-		return nil, err
-	}
-	defer zshrc.Close()
-
 	t := template.Must(template.New("zshrc").Parse(baseScript + runRunbookScript))
-
-	if err := t.Execute(zshrc, z); err != nil {
+	tmp, err := z.writeZshrc(t)
+	if err != nil {
 		return nil, err
 	}
 
 	cmd := exec.CommandContext(ctx, z.shellCmd)
 	cmd.Env = append(os.Environ(), "ZDOTDIR="+tmp)
-	cmd.Env = append(cmd.Env, runbookRunMetadata(runbook, z)...)
+	cmd.Env = append(cmd.Env, runbookRunMetadata(runbook, z, z.SocketPath)...)
 	cmd.WaitDelay = 500 * time.Millisecond
 	return cmd, nil
 }
 
+// writeZshrc executes t into a .zshrc in a new temporary directory and returns the directory.
+// The directory is used as ZDOTDIR, so that concurrent sessions don't overwrite each others zshrc.
+func (z *zsh) writeZshrc(t *template.Template) (string, error) {
+	tmp, err := os.MkdirTemp("", "savvy-zsh-*")
+	if err != nil {
+		return "", err
+	}
+	z.track(tmp)
+
+	zshrc, err := os.Create(filepath.Join(tmp, ".zshrc"))
+	if err != nil {
+		return "", err
+	}
+	defer zshrc.Close()
+
+	if err := t.Execute(zshrc, z); err != nil {
+		return "", err
+	}
+	return tmp, nil
+}
+
+func (z *zsh) RecordHereSnippet(journalPath string) (string, error) {
+	return posixRecordHereSnippet(z.SocketPath, journalPath), nil
+}
+
+func (z *zsh) JoinSnippet(terminal string) (string, error) {
+	return posixJoinSnippet(z.SocketPath, terminal), nil
+}
+
 func (z *zsh) DefaultStartingArrayIndex() int {
 	return 1
 }
@@ -303,12 +312,13 @@ func nextRunbookStepToRun(sh Shell) int {
 	return idx
 }
 
-func runbookRunMetadata(runbook *client.Runbook, sh Shell) []string {
+func runbookRunMetadata(runbook *client.Runbook, sh Shell, socketPath string) []string {
 	runbookCommands := strings.Join(runbook.Commands(), RunbookCommandDelimiter)
 	runbookAlias := computeRunbookAlias(runbook)
 
 	return []string{
 		"SAVVY_CONTEXT=run",
+		fmt.Sprintf("%s=%s", run.SocketPathEnv, socketPath),
 		fmt.Sprintf("SAVVY_RUNBOOK_COMMANDS=%s", runbookCommands),
 		fmt.Sprintf("SAVVY_NEXT_STEP=%d", nextRunbookStepToRun(sh)),
 		fmt.Sprintf("SAVVY_RUNBOOK_ALIAS=%s", runbookAlias),